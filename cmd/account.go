@@ -1,24 +1,79 @@
 package main
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Account struct {
-	ActiveCard     bool `json:"activeCard"`
-	AvailableLimit int  `json:"availableLimit"`
-	transactions   []Transaction
+	ID                   int                   `json:"accountId,omitempty"`
+	CardStatus           CardStatus            `json:"cardStatus,omitempty"`
+	BlockReason          string                `json:"blockReason,omitempty"`
+	Currency             Currency              `json:"currency,omitempty"`
+	CreditLimit          Money                 `json:"creditLimit,omitempty"`
+	AvailableLimit       Money                 `json:"availableLimit"`
+	SpendingLimits       *SpendingLimits       `json:"spendingLimits,omitempty"`
+	VelocityLimits       *VelocityLimits       `json:"velocityLimits,omitempty"`
+	Merchants            *MerchantLists        `json:"merchants,omitempty"`
+	Categories           *CategoryPolicy       `json:"categories,omitempty"`
+	SimilarityExemptions []SimilarityExemption `json:"similarityExemptions,omitempty"`
+	history              History
+	refundable           Refundable
+	version              int
+	// conversion reports how the transaction that produced this state was charged when it was
+	// informed in a foreign currency. It is never stored along with the account.
+	conversion *Conversion
+	// approval reports when the transaction that produced this state was partially approved.
+	approval *Approval
 }
 
-func (acc *Account) countMatches(newTransaction Transaction) matches {
-	matches := matches{}
-	for _, t := range acc.transactions {
-		minutesSinceLastTransaction := newTransaction.Time.Sub(t.Time).Minutes()
-		if minutesSinceLastTransaction > IntervalMinutes {
-			continue
-		}
-		if newTransaction.isSimilar(t) {
-			matches.similarity++
+func (acc Account) accountID() int {
+	return acc.ID
+}
+
+func (acc Account) ActiveCard() bool {
+	return acc.CardStatus == CardActive
+}
+
+func (acc Account) usedLimit() Money {
+	return acc.CreditLimit - acc.AvailableLimit
+}
+
+func (acc Account) MarshalJSON() ([]byte, error) {
+	type account Account
+	return json.Marshal(struct {
+		account
+		ActiveCard bool `json:"activeCard"`
+	}{account(acc), acc.ActiveCard()})
+}
+
+// UnmarshalJSON keeps accepting the legacy activeCard flag when no cardStatus is informed.
+func (acc *Account) UnmarshalJSON(data []byte) error {
+	type account Account
+	payload := struct {
+		account
+		ActiveCard bool `json:"activeCard"`
+	}{account: account(*acc)}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	*acc = Account(payload.account)
+	if acc.CardStatus == "" {
+		acc.CardStatus = CardInactive
+		if payload.ActiveCard {
+			acc.CardStatus = CardActive
 		}
-		matches.frequency++
 	}
-	return matches
+	if !acc.CardStatus.isValid() {
+		return fmt.Errorf("unknown card status %q", acc.CardStatus)
+	}
+	return nil
+}
+
+func countMatches(history History, newTransaction Transaction, intervalMinutes int) matches {
+	return history.countSince(newTransaction, newTransaction.Time.Add(-time.Duration(intervalMinutes)*time.Minute))
 }
 
 type matches struct {
@@ -2,65 +2,515 @@ package main
 
 import (
 	"errors"
+	"time"
 )
 
+// AccountManager records every change as an Event and derives the new account state by
+// folding it, keeping db as a projection of the events.
 type AccountManager struct {
-	db DB
+	db         DB
+	rules      *RuleRegistry
+	events     EventStore
+	rates      *RateTable
+	categories *CategoryRegistry
+	holdExpiry time.Duration
 }
 
+// NewAccountManager evaluates the default rules, recording events in memory. The With methods
+// change each of its collaborators.
 func NewAccountManager(db DB) *AccountManager {
-	return &AccountManager{db}
+	return &AccountManager{
+		db:         db,
+		rules:      NewDefaultRuleRegistry(DefaultConfig()),
+		events:     NewMemoryEventStore(),
+		rates:      NewRateTable(),
+		categories: NewCategoryRegistry(),
+		holdExpiry: DefaultHoldExpiryMinutes * time.Minute,
+	}
+}
+
+// WithRules changes the rules evaluated to authorize transactions.
+func (m *AccountManager) WithRules(rules *RuleRegistry) *AccountManager {
+	m.rules = rules
+	return m
+}
+
+// WithEvents changes the store recording the events of every account.
+func (m *AccountManager) WithEvents(events EventStore) *AccountManager {
+	m.events = events
+	return m
+}
+
+// WithRates changes the rates converting transactions informed in a foreign currency.
+func (m *AccountManager) WithRates(rates *RateTable) *AccountManager {
+	m.rates = rates
+	return m
+}
+
+// WithCategories changes the registry resolving the category of transactions.
+func (m *AccountManager) WithCategories(categories *CategoryRegistry) *AccountManager {
+	m.categories = categories
+	return m
+}
+
+// WithHoldExpiry changes how long holds reserve limit before being released when not captured.
+func (m *AccountManager) WithHoldExpiry(expiry time.Duration) *AccountManager {
+	m.holdExpiry = expiry
+	return m
 }
 
 func (m *AccountManager) Initialize(acc Account) (Account, []error) {
-	var errs []error
+	if acc.CardStatus != "" && !acc.CardStatus.isValid() {
+		return acc, []error{errors.New(InvalidCardStatus)}
+	}
+	if acc.CreditLimit == 0 {
+		acc.CreditLimit = acc.AvailableLimit
+	}
+	if acc.AvailableLimit < 0 || acc.CreditLimit < 0 {
+		return acc, []error{errors.New(InvalidAmount)}
+	}
+	if acc.AvailableLimit > acc.CreditLimit {
+		return acc, []error{errors.New(AvailableLimitAboveCredit)}
+	}
+	if acc.SpendingLimits != nil {
+		if err := acc.SpendingLimits.validate(); err != nil {
+			return acc, []error{err}
+		}
+	}
+	if acc.VelocityLimits != nil {
+		if err := acc.VelocityLimits.validate(); err != nil {
+			return acc, []error{err}
+		}
+	}
+	if acc.Merchants != nil {
+		if err := acc.Merchants.validate(); err != nil {
+			return acc, []error{err}
+		}
+	}
+	if acc.Categories != nil {
+		if err := acc.Categories.validate(m.categories); err != nil {
+			return acc, []error{err}
+		}
+	}
+	if err := validateExemptions(acc.SimilarityExemptions); err != nil {
+		return acc, []error{err}
+	}
+
+	created, err := m.record(acc, Event{Type: AccountCreated, Account: &acc})
+	var storageErr *StorageError
+	switch {
+	case errors.As(err, &storageErr):
+		return acc, []error{err}
+	case errors.Is(err, ErrVersionConflict):
+		existing, _ := m.db.FindAccount(acc.ID)
+		return existing, []error{errors.New(AccountAlreadyInitialized)}
+	case err != nil:
+		return created, []error{errors.New(AccountAlreadyInitialized)}
+	}
+	return created, nil
+}
+
+func (m *AccountManager) Authorize(acc Account, tr Transaction) (Account, []error) {
+	if tr.IdempotencyKey != "" {
+		decision, err := m.db.FindDecision(acc.ID, tr.IdempotencyKey)
+		if err == nil {
+			return decision.Account, decision.Errors
+		}
+	}
 
-	acc, err := m.db.CreateAccount(acc)
+	acc, errs := m.expireHolds(acc, tr.Time)
+	if errs != nil {
+		return acc, errs
+	}
+
+	tr.Category = m.categories.Categorize(tr)
+	tr, conversion, errs := m.convert(acc, tr)
+	if errs == nil {
+		errs = validateTransaction(acc, tr)
+	}
+	var approval *Approval
+	if errs == nil {
+		tr, approval = approvePartially(acc, tr)
+		acc.history = acc.history.since(tr.Time.Add(-m.rules.Window()))
+		errs = m.rules.Evaluate(acc, tr)
+	}
+
+	var err error
+	if errs == nil {
+		if approval != nil && conversion != nil {
+			scaled := conversion.scaledTo(tr.Amount)
+			conversion = &scaled
+		}
+		acc, err = m.record(acc, Event{Type: TransactionAuthorized, Transaction: &tr, Conversion: conversion, Approval: approval})
+	} else {
+		approval = nil
+		acc, err = m.record(acc, Event{Type: TransactionDeclined, Transaction: &tr, Conversion: conversion, Violations: violations(errs)})
+	}
 	if err != nil {
-		errs = append(errs, errors.New(AccountAlreadyInitialized))
+		return acc, []error{err}
 	}
+	acc.conversion = conversion
+	acc.approval = approval
 
 	return acc, errs
 }
 
-func (m *AccountManager) Authorize(acc Account, tr Transaction) (Account, []error) {
-	var errs []error
+// accountChange is a request changing the settings of an account, recorded as the event it
+// produces once validated against the account.
+type accountChange interface {
+	accountRequest
+	changeEvent(acc Account, categories *CategoryRegistry) (Event, error)
+}
 
-	if acc.AvailableLimit-tr.Amount < 0 {
-		errs = append(errs, errors.New(InsufficientLimit))
+func (m *AccountManager) Update(acc Account, change accountChange) (Account, []error) {
+	if au, ok := change.(AccountUpdate); ok {
+		var errs []error
+		if acc, errs = m.expireHolds(acc, au.Time); errs != nil {
+			return acc, errs
+		}
 	}
-	if !acc.ActiveCard {
-		errs = append(errs, errors.New(CardNotActive))
+
+	changed, err := change.changeEvent(acc, m.categories)
+	if err != nil {
+		return acc, []error{err}
 	}
-	matches := acc.countMatches(tr)
-	if matches.frequency == MaxFrequencyPerInterval {
-		errs = append(errs, errors.New(HighFrequencySmallInterval))
+	return m.change(acc, changed)
+}
+
+func (au AccountUpdate) changeEvent(acc Account, _ *CategoryRegistry) (Event, error) {
+	if au.CreditLimit < 0 {
+		return Event{}, errors.New(InvalidAmount)
 	}
-	if matches.similarity == MaxSimilarityPerInterval {
-		errs = append(errs, errors.New(DoubledTransaction))
+	if au.CreditLimit < acc.usedLimit() {
+		return Event{}, errors.New(CreditLimitBelowUsage)
 	}
+	return Event{Type: CreditLimitChanged, CreditLimit: au.CreditLimit}, nil
+}
 
-	if errs == nil {
-		acc = m.db.UpdateAccount(Account{
-			ActiveCard:     acc.ActiveCard,
-			AvailableLimit: acc.AvailableLimit - tr.Amount,
-			transactions:   append(acc.transactions, tr),
-		})
+// changeEvent removes every spending limit when none is informed.
+func (su SpendingLimitsUpdate) changeEvent(_ Account, _ *CategoryRegistry) (Event, error) {
+	if err := su.SpendingLimits.validate(); err != nil {
+		return Event{}, err
 	}
+	changed := Event{Type: SpendingLimitsChanged}
+	if su.SpendingLimits != (SpendingLimits{}) {
+		changed.SpendingLimits = &su.SpendingLimits
+	}
+	return changed, nil
+}
 
-	return acc, errs
+// changeEvent removes every velocity limit when none is informed.
+func (vu VelocityLimitsUpdate) changeEvent(_ Account, _ *CategoryRegistry) (Event, error) {
+	if err := vu.VelocityLimits.validate(); err != nil {
+		return Event{}, err
+	}
+	changed := Event{Type: VelocityLimitsChanged}
+	if vu.VelocityLimits != (VelocityLimits{}) {
+		changed.VelocityLimits = &vu.VelocityLimits
+	}
+	return changed, nil
 }
 
-const (
-	IntervalMinutes          = 2
-	MaxFrequencyPerInterval  = 3
-	MaxSimilarityPerInterval = 1
-)
+// changeEvent blocks or allows a merchant pattern, or switches whether only the allowed merchants
+// are accepted.
+func (op MerchantOperation) changeEvent(acc Account, _ *CategoryRegistry) (Event, error) {
+	merchants, err := acc.Merchants.change(op)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: MerchantListsChanged, Merchants: merchants}, nil
+}
+
+// changeEvent only accepts categories known to the registry, and removes the policy when nothing
+// is restricted.
+func (cu CategoryPolicyUpdate) changeEvent(_ Account, categories *CategoryRegistry) (Event, error) {
+	if err := cu.CategoryPolicy.validate(categories); err != nil {
+		return Event{}, err
+	}
+	changed := Event{Type: CategoryPolicyChanged}
+	if !cu.CategoryPolicy.isEmpty() {
+		changed.Categories = &cu.CategoryPolicy
+	}
+	return changed, nil
+}
+
+func (eu SimilarityExemptionsUpdate) changeEvent(_ Account, _ *CategoryRegistry) (Event, error) {
+	if err := validateExemptions(eu.Exemptions); err != nil {
+		return Event{}, err
+	}
+	changed := Event{Type: SimilarityExemptionsChanged}
+	if len(eu.Exemptions) > 0 {
+		changed.SimilarityExemptions = eu.Exemptions
+	}
+	return changed, nil
+}
+
+// changeEvent requires a reason to block the card.
+func (op CardOperation) changeEvent(acc Account, _ *CategoryRegistry) (Event, error) {
+	if op.Action == BlockCard && op.Reason == "" {
+		return Event{}, errors.New(BlockReasonRequired)
+	}
+
+	status, err := acc.CardStatus.transition(op.Action)
+	if err != nil {
+		return Event{}, err
+	}
+
+	changed := Event{Type: CardStatusChanged, CardStatus: status}
+	if status == CardBlocked {
+		changed.BlockReason = op.Reason
+	}
+	return changed, nil
+}
+
+func (m *AccountManager) Refund(acc Account, rf Refund) (Account, []error) {
+	acc, errs := m.expireHolds(acc, rf.Time)
+	if errs != nil {
+		return acc, errs
+	}
+
+	original, found := acc.refundable.find(rf.TransactionID)
+	if !found {
+		return acc, []error{errors.New(OriginalTransactionNotFound)}
+	}
+	if original.Hold {
+		return acc, []error{errors.New(HoldNotCaptured)}
+	}
+	remaining := original.Amount - original.refunded
+	amount := rf.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount < 0 {
+		return acc, []error{errors.New(InvalidAmount)}
+	}
+	if amount > remaining {
+		return acc, []error{errors.New(RefundExceedsOriginal)}
+	}
+
+	refunded := Event{Type: TransactionRefunded, TransactionID: original.ID, Amount: amount}
+	return m.change(acc, refunded)
+}
+
+func (m *AccountManager) Capture(acc Account, cp Capture) (Account, []error) {
+	acc, errs := m.expireHolds(acc, cp.Time)
+	if errs != nil {
+		return acc, errs
+	}
+
+	hold, found := acc.refundable.find(cp.TransactionID)
+	if !found {
+		return acc, []error{errors.New(OriginalTransactionNotFound)}
+	}
+	if !hold.Hold {
+		return acc, []error{errors.New(HoldNotFound)}
+	}
+
+	amount := cp.Amount
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount < 0 {
+		return acc, []error{errors.New(InvalidAmount)}
+	}
+	if amount > hold.Amount && amount-hold.Amount > acc.AvailableLimit {
+		return acc, []error{errors.New(InsufficientLimit)}
+	}
+
+	return m.change(acc, Event{Type: TransactionCaptured, TransactionID: hold.ID, Amount: amount})
+}
+
+func (m *AccountManager) Reverse(acc Account, rv Reversal) (Account, []error) {
+	acc, errs := m.expireHolds(acc, rv.Time)
+	if errs != nil {
+		return acc, errs
+	}
+
+	original, found := acc.refundable.find(rv.TransactionID)
+	if !found {
+		return acc, []error{errors.New(OriginalTransactionNotFound)}
+	}
+
+	reversed := Event{Type: TransactionReversed, TransactionID: original.ID}
+	return m.change(acc, reversed)
+}
+
+// convert charges transactions informed in a foreign currency in the account currency, so rules
+// and the history only ever see amounts in the account currency.
+func (m *AccountManager) convert(acc Account, tr Transaction) (Transaction, *Conversion, []error) {
+	currency := acc.Currency.orDefault()
+	if tr.Currency.orDefault() == currency {
+		return tr, nil, nil
+	}
+	if tr.Amount < 0 {
+		return tr, nil, []error{errors.New(InvalidAmount)}
+	}
+
+	conversion, found, err := m.rates.Convert(tr, currency)
+	if !found {
+		return tr, nil, []error{errors.New(CurrencyMismatch)}
+	}
+	if err != nil {
+		return tr, nil, []error{err}
+	}
+	tr.Amount, _ = conversion.Charged()
+	tr.Currency = acc.Currency
+	return tr, &conversion, nil
+}
+
+// approvePartially lowers the amount of transactions allowing partial approval down to the
+// available limit, so the rules evaluate the amount that would actually be debited.
+func approvePartially(acc Account, tr Transaction) (Transaction, *Approval) {
+	if !tr.AllowPartial || tr.Amount <= acc.AvailableLimit || acc.AvailableLimit <= 0 {
+		return tr, nil
+	}
+	approval := &Approval{Status: PartiallyApproved, RequestedAmount: tr.Amount, ApprovedAmount: acc.AvailableLimit}
+	tr.Amount = acc.AvailableLimit
+	return tr, approval
+}
+
+// expireHolds releases the limit reserved by holds that were not captured within the expiry
+// period before the given time, which is the time of the request being processed. Nothing expires
+// when the request informs no time.
+func (m *AccountManager) expireHolds(acc Account, now time.Time) (Account, []error) {
+	for _, tr := range acc.refundable.Holds() {
+		if now.Before(tr.Time.Add(m.holdExpiry)) {
+			continue
+		}
+		var err error
+		acc, err = m.record(acc, Event{Type: HoldExpired, TransactionID: tr.ID})
+		if err != nil {
+			return acc, []error{err}
+		}
+	}
+	return acc, nil
+}
+
+// validateTransaction declines transactions whose amount cannot be debited from the account,
+// before any rule compares it with the account limits.
+func validateTransaction(acc Account, tr Transaction) []error {
+	if tr.Hold && tr.ID == "" {
+		return []error{errors.New(TransactionIDRequired)}
+	}
+	if _, found := acc.refundable.find(tr.ID); found {
+		return []error{errors.New(DuplicateTransactionID)}
+	}
+	if tr.Amount < 0 {
+		return []error{errors.New(InvalidAmount)}
+	}
+	if tr.MCC != "" && !isMCC(tr.MCC) {
+		return []error{errors.New(InvalidMCC)}
+	}
+	if _, err := acc.AvailableLimit.Sub(tr.Amount); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// record appends an event about the account and, within the same step, stores what it produces,
+// failing with ErrVersionConflict when the account changed since it was read. Once the event is
+// durable the request is no longer failed by db being unable to store its projection, as db
+// keeps the change all the same and Rebuild stores it again from the event on restart.
+func (m *AccountManager) record(acc Account, e Event) (Account, error) {
+	e.AccountID = acc.ID
+	e.Version = acc.version + 1
+	if e.Type == TransactionDeclined {
+		e.Version = acc.version
+	}
+
+	var projected error
+	_, err := m.events.Append(e, func(e Event) {
+		acc, projected = m.project(acc, e)
+	})
+	if err != nil {
+		return acc, err
+	}
+	var storageErr *StorageError
+	if errors.As(projected, &storageErr) {
+		return acc, nil
+	}
+	return acc, projected
+}
+
+// project stores the state the event produces from acc, along with the decision it carries
+// when its request had an idempotency key. The decision is stored even when db could not make
+// the state durable, since it keeps the state anyway.
+func (m *AccountManager) project(acc Account, e Event) (Account, error) {
+	var err error
+	switch e.Type {
+	case AccountCreated:
+		acc, err = m.db.CreateAccount(e.apply(Account{}))
+	case TransactionDeclined:
+	default:
+		acc, err = m.db.UpdateAccount(e.apply(acc), e)
+	}
+	var storageErr *StorageError
+	if err != nil && !errors.As(err, &storageErr) {
+		return acc, err
+	}
+
+	if key := e.idempotencyKey(); key != "" {
+		if saveErr := m.db.SaveDecision(acc.ID, key, e.decision(acc)); saveErr != nil {
+			err = saveErr
+		}
+	}
+	return acc, err
+}
+
+func (m *AccountManager) change(acc Account, e Event) (Account, []error) {
+	acc, err := m.record(acc, e)
+	if err != nil {
+		return acc, []error{err}
+	}
+	return acc, nil
+}
 
 const (
-	AccountAlreadyInitialized  = "account-already-initialized"
-	InsufficientLimit          = "insufficient-limit"
-	CardNotActive              = "card-not-active"
-	HighFrequencySmallInterval = "high-frequency-small-interval"
-	DoubledTransaction         = "doubled-transaction"
+	AccountAlreadyInitialized   = "account-already-initialized"
+	AccountNotInitialized       = "account-not-initialized"
+	InsufficientLimit           = "insufficient-limit"
+	CardNotActive               = "card-not-active"
+	HighFrequencySmallInterval  = "high-frequency-small-interval"
+	DoubledTransaction          = "doubled-transaction"
+	CardIsBlocked               = "card-blocked"
+	CardIsClosed                = "card-closed"
+	CardAlreadyActive           = "card-already-active"
+	CardAlreadyBlocked          = "card-already-blocked"
+	CardNotBlocked              = "card-not-blocked"
+	BlockReasonRequired         = "block-reason-required"
+	InvalidCardAction           = "invalid-card-action"
+	InvalidCardStatus           = "invalid-card-status"
+	OriginalTransactionNotFound = "original-transaction-not-found"
+	RefundExceedsOriginal       = "refund-exceeds-original"
+	InvalidAmount               = "invalid-amount"
+	CreditLimitBelowUsage       = "credit-limit-below-usage"
+	AvailableLimitAboveCredit   = "available-limit-above-credit-limit"
+	AccountVersionConflict      = "account-version-conflict"
+	StorageUnavailable          = "storage-unavailable"
+	CurrencyMismatch            = "currency-mismatch"
+	AmountOverflow              = "amount-overflow"
+	TransactionIDRequired       = "transaction-id-required"
+	DuplicateTransactionID      = "duplicate-transaction-id"
+	HoldNotFound                = "hold-not-found"
+	HoldNotCaptured             = "hold-not-captured"
+	PartiallyApproved           = "partially-approved"
+	InvalidTimeZone             = "invalid-time-zone"
+	TransactionLimitExceeded    = "transaction-limit-exceeded"
+	DailyLimitExceeded          = "daily-limit-exceeded"
+	MonthlyLimitExceeded        = "monthly-limit-exceeded"
+	InvalidVelocityLimits       = "invalid-velocity-limits"
+	HighAmountSmallInterval     = "high-amount-small-interval"
+	ManyMerchantsSmallInterval  = "many-merchants-small-interval"
+	MerchantBlocked             = "merchant-blocked"
+	MerchantNotAllowed          = "merchant-not-allowed"
+	MerchantPatternRequired     = "merchant-pattern-required"
+	MerchantAlreadyListed       = "merchant-already-listed"
+	MerchantNotListed           = "merchant-not-listed"
+	InvalidMerchantAction       = "invalid-merchant-action"
+	InvalidMCC                  = "invalid-mcc"
+	UnknownCategory             = "unknown-category"
+	CategoryBlocked             = "category-blocked"
+	CategoryNotAllowed          = "category-not-allowed"
+	CategoryLimitExceeded       = "category-limit-exceeded"
+	InvalidSimilarityExemption  = "invalid-similarity-exemption"
 )
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"math"
 	"testing"
 	"time"
 
@@ -14,32 +15,39 @@ func TestInitializeAccount(t *testing.T) {
 		"Should initialize account when there are no accounts": func(t *testing.T) {
 			// given
 			input := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 123,
 			}
+			expected := Account{
+				CardStatus:     CardActive,
+				CreditLimit:    123,
+				AvailableLimit: 123,
+				version:        1,
+			}
 			db := NewDatabaseMock()
-			db.On("CreateAccount", input).Return(input, nil)
+			db.On("CreateAccount", expected).Return(expected, nil)
 			m := NewAccountManager(db)
 
 			// when
 			output, errs := m.Initialize(input)
 
 			// then
-			assert.Equal(t, input, output)
+			assert.Equal(t, expected, output)
 			assert.Empty(t, errs)
 		},
 		"Should not initialize account when an account is already initialized": func(t *testing.T) {
 			// given
 			current := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 123,
 			}
 			input := Account{
-				ActiveCard:     false,
+				CardStatus:     CardInactive,
+				CreditLimit:    456,
 				AvailableLimit: 456,
 			}
 			db := NewDatabaseMock()
-			db.On("CreateAccount", input).Return(current, errors.New("error"))
+			db.On("CreateAccount", mock.AnythingOfType("Account")).Return(current, errors.New("error"))
 			m := NewAccountManager(db)
 
 			// when
@@ -50,6 +58,85 @@ func TestInitializeAccount(t *testing.T) {
 			assert.Len(t, errs, 1)
 			assert.Contains(t, errs, errors.New(AccountAlreadyInitialized))
 		},
+		"Should not initialize account when its event cannot be stored": func(t *testing.T) {
+			// given
+			input := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 123,
+			}
+			events, _ := OpenFileEventStore(t.TempDir())
+			events.Close()
+			db := NewDatabaseMock()
+			m := NewAccountManager(db).WithEvents(events)
+
+			// when
+			_, errs := m.Initialize(input)
+
+			// then
+			assert.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], StorageUnavailable)
+			assert.Empty(t, events.Events())
+			db.AssertNotCalled(t, "CreateAccount", mock.Anything)
+		},
+		"Should initialize account whose event was stored even if its state could not be": func(t *testing.T) {
+			// given
+			input := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 123,
+			}
+			db := NewDatabaseMock()
+			db.On("CreateAccount", mock.AnythingOfType("Account")).Return(input, &StorageError{Err: errors.New("disk full")})
+			m := NewAccountManager(db)
+
+			// when
+			_, errs := m.Initialize(input)
+
+			// then
+			assert.Empty(t, errs)
+			assert.Len(t, m.events.Events(), 1)
+		},
+		"Should not initialize account twice": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+
+			// when
+			output, errs := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 500})
+
+			// then
+			assert.Equal(t, []error{errors.New(AccountAlreadyInitialized)}, errs)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Len(t, m.events.Events(), 1)
+		},
+		"Should not initialize account with negative limits or more available than credit limit": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+
+			// when
+			_, negative := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: -10})
+			_, negativeCredit := m.Initialize(Account{ID: 1, CardStatus: CardActive, CreditLimit: -10})
+			_, above := m.Initialize(Account{ID: 1, CardStatus: CardActive, CreditLimit: 20, AvailableLimit: 100})
+			below, errs := m.Initialize(Account{ID: 1, CardStatus: CardActive, CreditLimit: 100, AvailableLimit: 20})
+
+			// then
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, negative)
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, negativeCredit)
+			assert.Equal(t, []error{errors.New(AvailableLimitAboveCredit)}, above)
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(80), below.usedLimit())
+		},
+		"Should not initialize account with unknown card status": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+
+			// when
+			_, errs := m.Initialize(Account{ID: 1, CardStatus: CardStatus("frozen"), AvailableLimit: 100})
+			_, found := m.db.FindAccount(1)
+
+			// then
+			assert.Equal(t, []error{errors.New(InvalidCardStatus)}, errs)
+			assert.Error(t, found)
+		},
 	}
 
 	for name, run := range tests {
@@ -64,11 +151,11 @@ func TestAuthorizeTransaction(t *testing.T) {
 		"Should authorize transaction with no violations": func(t *testing.T) {
 			// given
 			account := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 			db := NewDatabaseMock()
-			db.On("UpdateAccount", mock.AnythingOfType("Account"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
 			m := NewAccountManager(db)
 
 			// when
@@ -79,18 +166,18 @@ func TestAuthorizeTransaction(t *testing.T) {
 			})
 
 			// then
-			assert.Equal(t, 80, output.AvailableLimit)
-			assert.Len(t, output.transactions, 1)
+			assert.Equal(t, Money(80), output.AvailableLimit)
+			assert.Equal(t, 1, output.history.Len())
 			assert.Empty(t, errs)
 		},
 		"Should not authorize transaction due to insufficient limit violation": func(t *testing.T) {
 			// given
 			account := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 			db := NewDatabaseMock()
-			db.On("UpdateAccount", mock.AnythingOfType("Account"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
 			m := NewAccountManager(db)
 
 			// when
@@ -101,19 +188,19 @@ func TestAuthorizeTransaction(t *testing.T) {
 			})
 
 			// then
-			assert.Equal(t, 100, output.AvailableLimit)
-			assert.Len(t, output.transactions, 0)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Equal(t, 0, output.history.Len())
 			assert.Len(t, errs, 1)
 			assert.Contains(t, errs, errors.New(InsufficientLimit))
 		},
 		"Should not authorize transaction due to card not active violation": func(t *testing.T) {
 			// given
 			account := Account{
-				ActiveCard:     false,
+				CardStatus:     CardInactive,
 				AvailableLimit: 100,
 			}
 			db := NewDatabaseMock()
-			db.On("UpdateAccount", mock.AnythingOfType("Account"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
 			m := NewAccountManager(db)
 
 			// when
@@ -124,24 +211,24 @@ func TestAuthorizeTransaction(t *testing.T) {
 			})
 
 			// then
-			assert.Equal(t, 100, output.AvailableLimit)
-			assert.Len(t, output.transactions, 0)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Equal(t, 0, output.history.Len())
 			assert.Len(t, errs, 1)
 			assert.Contains(t, errs, errors.New(CardNotActive))
 		},
 		"Should not authorize transaction due to high frequency on small interval violation": func(t *testing.T) {
 			// given
 			account := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
-				transactions: []Transaction{
-					{Time: time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC)},
-					{Time: time.Date(2020, 7, 12, 10, 31, 0, 0, time.UTC)},
-					{Time: time.Date(2020, 7, 12, 10, 31, 30, 0, time.UTC)},
-				},
+				history: NewHistory(
+					Transaction{Time: time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC)},
+					Transaction{Time: time.Date(2020, 7, 12, 10, 31, 0, 0, time.UTC)},
+					Transaction{Time: time.Date(2020, 7, 12, 10, 31, 30, 0, time.UTC)},
+				),
 			}
 			db := NewDatabaseMock()
-			db.On("UpdateAccount", mock.AnythingOfType("Account"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
 			m := NewAccountManager(db)
 
 			// when
@@ -152,26 +239,24 @@ func TestAuthorizeTransaction(t *testing.T) {
 			})
 
 			// then
-			assert.Equal(t, 100, output.AvailableLimit)
-			assert.Len(t, output.transactions, 3)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Equal(t, 3, output.history.Len())
 			assert.Len(t, errs, 1)
 			assert.Contains(t, errs, errors.New(HighFrequencySmallInterval))
 		},
 		"Should not authorize transaction due to doubled transaction violation": func(t *testing.T) {
 			// given
 			account := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
-				transactions: []Transaction{
-					{
-						Merchant: "Acme Corporation",
-						Amount:   20,
-						Time:     time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC),
-					},
-				},
+				history: NewHistory(Transaction{
+					Merchant: "Acme Corporation",
+					Amount:   20,
+					Time:     time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC),
+				}),
 			}
 			db := NewDatabaseMock()
-			db.On("UpdateAccount", mock.AnythingOfType("Account"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
 			m := NewAccountManager(db)
 
 			// when
@@ -182,11 +267,1351 @@ func TestAuthorizeTransaction(t *testing.T) {
 			})
 
 			// then
-			assert.Equal(t, 100, output.AvailableLimit)
-			assert.Len(t, output.transactions, 1)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Equal(t, 1, output.history.Len())
 			assert.Len(t, errs, 1)
 			assert.Contains(t, errs, errors.New(DoubledTransaction))
 		},
+		"Should not authorize transaction in another currency": func(t *testing.T) {
+			// given
+			account := Account{
+				CardStatus:     CardActive,
+				Currency:       BRL,
+				AvailableLimit: 100,
+			}
+			db := NewDatabaseMock()
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				Merchant: "Acme Corporation",
+				Amount:   20,
+				Currency: USD,
+				Time:     time.Now(),
+			})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(CurrencyMismatch)}, errs)
+			db.AssertNumberOfCalls(t, "UpdateAccount", 0)
+		},
+		"Should authorize transaction in another currency converting it with the rate table": func(t *testing.T) {
+			// given
+			account := Account{
+				CardStatus:     CardActive,
+				Currency:       BRL,
+				AvailableLimit: Units(100),
+			}
+			rates := NewRateTable()
+			rates.Add(USD, BRL, 5000000, time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC))
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			events := NewMemoryEventStore()
+			m := NewAccountManager(db).WithEvents(events).WithRates(rates.WithFee(40000))
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				Merchant: "Acme Corporation",
+				Amount:   Units(10),
+				Currency: USD,
+				Time:     time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC),
+			})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Units(48), output.AvailableLimit)
+			assert.Equal(t, &Conversion{
+				OriginalAmount:   Units(10),
+				OriginalCurrency: USD,
+				Rate:             5000000,
+				ConvertedAmount:  Units(50),
+				Fee:              Units(2),
+				Currency:         BRL,
+			}, output.conversion)
+			recorded := events.Events()[0]
+			assert.Equal(t, Units(52), recorded.Transaction.Amount)
+			assert.Equal(t, output.conversion, recorded.Conversion)
+		},
+		"Should not authorize converted transaction exceeding the available limit": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: Units(40)}
+			rates := NewRateTable()
+			rates.Add(USD, BRL, 5000000, time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC))
+			m := NewAccountManager(NewDatabaseMock()).WithRates(rates)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				Merchant: "Acme Corporation",
+				Amount:   Units(10),
+				Currency: USD,
+				Time:     time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC),
+			})
+
+			// then
+			assert.Equal(t, []error{errors.New(InsufficientLimit)}, errs)
+			assert.Equal(t, Units(40), output.AvailableLimit)
+			assert.Equal(t, Units(50), output.conversion.ConvertedAmount)
+		},
+		"Should authorize transaction without currency in the default currency": func(t *testing.T) {
+			// given
+			account := Account{
+				CardStatus:     CardActive,
+				Currency:       DefaultCurrency,
+				AvailableLimit: 100,
+			}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{Merchant: "Acme Corporation", Amount: 20, Time: time.Now()})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(80), output.AvailableLimit)
+		},
+		"Should not authorize transaction with negative amount": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: 100}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Authorize(account, Transaction{Merchant: "Acme Corporation", Amount: -20, Time: time.Now()})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, errs)
+		},
+		"Should not authorize transaction overflowing the available limit": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: math.MinInt64 + 1}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			_, errs := m.Authorize(account, Transaction{Merchant: "Acme Corporation", Amount: 2, Time: time.Now()})
+
+			// then
+			assert.Equal(t, []error{ErrAmountOverflow}, errs)
+		},
+		"Should evict transactions older than the largest rule window": func(t *testing.T) {
+			// given
+			account := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+				history: NewHistory(
+					Transaction{Merchant: "Acme Corporation", Amount: 20, Time: time.Date(2020, 6, 10, 10, 0, 0, 0, time.UTC)},
+					Transaction{Merchant: "Acme Corporation", Amount: 20, Time: time.Date(2020, 7, 12, 10, 29, 0, 0, time.UTC)},
+				),
+			}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				Merchant: "Acme Corporation",
+				Amount:   10,
+				Time:     time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC),
+			})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, 2, output.history.Len())
+			assert.Equal(t, time.Date(2020, 7, 12, 10, 29, 0, 0, time.UTC), output.history.Transactions()[0].Time)
+		},
+		"Should not authorize transaction due to custom rule violation": func(t *testing.T) {
+			// given
+			account := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			rules := NewDefaultRuleRegistry(DefaultConfig())
+			_ = rules.Register("no-weekends", 50, RuleFunc(func(_ Account, tr Transaction, _ History) []error {
+				if tr.Time.Weekday() == time.Saturday || tr.Time.Weekday() == time.Sunday {
+					return []error{errors.New("no-weekends")}
+				}
+				return nil
+			}))
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db).WithRules(rules)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				Merchant: "Acme Corporation",
+				Amount:   20,
+				Time:     time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC),
+			})
+
+			// then
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Equal(t, 0, output.history.Len())
+			assert.Len(t, errs, 1)
+			assert.Contains(t, errs, errors.New("no-weekends"))
+		},
+		"Should store decision of transaction with idempotency key": func(t *testing.T) {
+			// given
+			account := Account{
+				ID:             1,
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			tr := Transaction{
+				AccountID:      1,
+				Merchant:       "Acme Corporation",
+				Amount:         20,
+				Time:           time.Now(),
+				IdempotencyKey: "order-1",
+			}
+			db := NewDatabaseMock()
+			db.On("FindDecision", 1, "order-1").Return(Decision{}, errors.New("decision not found"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			db.On("SaveDecision", 1, "order-1", mock.AnythingOfType("Decision"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, tr)
+
+			// then
+			assert.Equal(t, Money(80), output.AvailableLimit)
+			assert.Empty(t, errs)
+			db.AssertNumberOfCalls(t, "SaveDecision", 1)
+		},
+		"Should authorize transaction whose decision could not be logged after its event": func(t *testing.T) {
+			// given
+			account := Account{
+				ID:             1,
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			db := NewDatabaseMock()
+			db.On("FindDecision", 1, "order-1").Return(Decision{}, errors.New("decision not found"))
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			db.On("SaveDecision", 1, "order-1", mock.AnythingOfType("Decision")).Return(&StorageError{Err: errors.New("disk full")})
+			m := NewAccountManager(db)
+
+			// when
+			_, errs := m.Authorize(account, Transaction{
+				AccountID:      1,
+				Merchant:       "Acme Corporation",
+				Amount:         20,
+				Time:           time.Now(),
+				IdempotencyKey: "order-1",
+			})
+
+			// then
+			assert.Empty(t, errs)
+			db.AssertNumberOfCalls(t, "SaveDecision", 1)
+		},
+		"Should replay decision of transaction with repeated idempotency key": func(t *testing.T) {
+			// given
+			account := Account{
+				ID:             1,
+				CardStatus:     CardActive,
+				AvailableLimit: 80,
+			}
+			original := Decision{
+				Account: Account{ID: 1, CardStatus: CardActive, AvailableLimit: 80},
+			}
+			db := NewDatabaseMock()
+			db.On("FindDecision", 1, "order-1").Return(original, nil)
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				AccountID:      1,
+				Merchant:       "Acme Corporation",
+				Amount:         20,
+				Time:           time.Now(),
+				IdempotencyKey: "order-1",
+			})
+
+			// then
+			assert.Equal(t, original.Account, output)
+			assert.Empty(t, errs)
+			db.AssertNumberOfCalls(t, "UpdateAccount", 0)
+			db.AssertNumberOfCalls(t, "SaveDecision", 0)
+		},
+		"Should not authorize transaction reusing the identifier of a transaction still kept": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+			account, _ = m.Authorize(account, Transaction{ID: "t1", Merchant: "Acme Corporation", Amount: 20, Time: now})
+
+			// when
+			output, errs := m.Authorize(account, Transaction{ID: "t1", Merchant: "Grand Hotel", Amount: 30, Time: now.Add(time.Hour), Hold: true})
+
+			// then
+			assert.Equal(t, []error{errors.New(DuplicateTransactionID)}, errs)
+			assert.Equal(t, Money(80), output.AvailableLimit)
+			assert.Equal(t, 1, output.refundable.Len())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestRefundTransaction(t *testing.T) {
+	transactions := []Transaction{
+		{ID: "t1", Merchant: "Acme Corporation", Amount: 30, Time: time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC)},
+		{ID: "t2", Merchant: "Acme Corporation", Amount: 20, Time: time.Date(2020, 7, 12, 10, 31, 0, 0, time.UTC)},
+	}
+	account := Account{
+		CardStatus:     CardActive,
+		AvailableLimit: 50,
+		history:        NewHistory(transactions...),
+		refundable:     NewRefundable(transactions...),
+	}
+
+	tests := map[string]func(*testing.T){
+		"Should partially refund transaction": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Refund(account, Refund{TransactionID: "t1", Amount: 10})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(60), output.AvailableLimit)
+			assert.Equal(t, 2, output.refundable.Len())
+			refunded, _ := output.refundable.find("t1")
+			assert.Equal(t, Money(10), refunded.refunded)
+			original, _ := account.refundable.find("t1")
+			assert.Equal(t, Money(0), original.refunded)
+			assert.Equal(t, 2, output.history.Len())
+		},
+		"Should fully refund transaction and remove it from history": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Refund(account, Refund{TransactionID: "t1"})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(80), output.AvailableLimit)
+			assert.Equal(t, 1, output.refundable.Len())
+			assert.Equal(t, []Transaction{transactions[1]}, output.refundable.Transactions())
+			assert.Equal(t, []Transaction{transactions[1]}, output.history.Transactions())
+		},
+		"Should not refund more than the remaining amount of the original transaction": func(t *testing.T) {
+			// given
+			refunded := account
+			refunded.refundable = account.refundable.with(Transaction{ID: "t1", Amount: 30, refunded: 25})
+			db := NewDatabaseMock()
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Refund(refunded, Refund{TransactionID: "t1", Amount: 10})
+
+			// then
+			assert.Equal(t, refunded, output)
+			assert.Equal(t, []error{errors.New(RefundExceedsOriginal)}, errs)
+			db.AssertNumberOfCalls(t, "UpdateAccount", 0)
+		},
+		"Should not refund a negative amount": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Refund(account, Refund{TransactionID: "t1", Amount: -10})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, errs)
+		},
+		"Should not refund unknown transaction": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Refund(account, Refund{TransactionID: "t3", Amount: 10})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(OriginalTransactionNotFound)}, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestPartialApproval(t *testing.T) {
+	account := Account{CardStatus: CardActive, AvailableLimit: 30}
+
+	tests := map[string]func(*testing.T){
+		"Should approve up to the available limit": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			events := NewMemoryEventStore()
+			m := NewAccountManager(db).WithEvents(events)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{Merchant: "Gift Shop", Amount: 50, Time: time.Now(), AllowPartial: true})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(0), output.AvailableLimit)
+			assert.Equal(t, &Approval{Status: PartiallyApproved, RequestedAmount: 50, ApprovedAmount: 30}, output.approval)
+			assert.Equal(t, Money(30), events.Events()[0].Transaction.Amount)
+			assert.Equal(t, output.approval, events.Events()[0].Approval)
+		},
+		"Should scale the conversion down to the approved amount": func(t *testing.T) {
+			// given
+			limited := Account{CardStatus: CardActive, Currency: BRL, AvailableLimit: 10050}
+			rates := NewRateTable()
+			rates.Add(USD, BRL, 5000000, time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC))
+			m := NewAccountManager(NewMemoryDB()).WithRates(rates.WithFee(40000))
+
+			// when
+			output, errs := m.Authorize(limited, Transaction{
+				Merchant:     "Acme Corporation",
+				Amount:       Units(30),
+				Currency:     USD,
+				Time:         time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC),
+				AllowPartial: true,
+			})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, &Approval{Status: PartiallyApproved, RequestedAmount: Units(156), ApprovedAmount: 10050}, output.approval)
+			assert.Equal(t, &Conversion{
+				OriginalAmount:   1933,
+				OriginalCurrency: USD,
+				Rate:             5000000,
+				ConvertedAmount:  9663,
+				Fee:              387,
+				Currency:         BRL,
+			}, output.conversion)
+		},
+		"Should approve the whole amount when the limit is enough": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{Merchant: "Gift Shop", Amount: 20, Time: time.Now(), AllowPartial: true})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(10), output.AvailableLimit)
+			assert.Nil(t, output.approval)
+		},
+		"Should still decline when there is no available limit": func(t *testing.T) {
+			// given
+			empty := Account{CardStatus: CardActive}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Authorize(empty, Transaction{Merchant: "Gift Shop", Amount: 20, Time: time.Now(), AllowPartial: true})
+
+			// then
+			assert.Equal(t, []error{errors.New(InsufficientLimit)}, errs)
+			assert.Nil(t, output.approval)
+		},
+		"Should still enforce the other rules": func(t *testing.T) {
+			// given
+			blocked := Account{CardStatus: CardBlocked, AvailableLimit: 30}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Authorize(blocked, Transaction{Merchant: "Gift Shop", Amount: 50, Time: time.Now(), AllowPartial: true})
+
+			// then
+			assert.Equal(t, []error{errors.New(CardIsBlocked)}, errs)
+			assert.Equal(t, Money(30), output.AvailableLimit)
+			assert.Nil(t, output.approval)
+		},
+		"Should decline without the flag": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			_, errs := m.Authorize(account, Transaction{Merchant: "Gift Shop", Amount: 50, Time: time.Now()})
+
+			// then
+			assert.Equal(t, []error{errors.New(InsufficientLimit)}, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestCaptureTransaction(t *testing.T) {
+	held := Transaction{ID: "t1", Merchant: "Grand Hotel", Amount: 30, Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC), Hold: true}
+	account := Account{
+		CardStatus:     CardActive,
+		AvailableLimit: 70,
+		history:        NewHistory(held),
+		refundable:     NewRefundable(held),
+	}
+	at := time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+
+	tests := map[string]func(*testing.T){
+		"Should capture the whole hold": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Capture(account, Capture{TransactionID: "t1", Time: at})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(70), output.AvailableLimit)
+			assert.Equal(t, []Transaction{{ID: "t1", Merchant: "Grand Hotel", Amount: 30, Time: held.Time}}, output.refundable.Transactions())
+		},
+		"Should release the rest of a partially captured hold": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Capture(account, Capture{TransactionID: "t1", Amount: 20, Time: at})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(80), output.AvailableLimit)
+			assert.Equal(t, Money(20), output.refundable.Transactions()[0].Amount)
+			assert.False(t, output.refundable.Transactions()[0].Hold)
+		},
+		"Should capture more than the hold when the limit allows it": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Capture(account, Capture{TransactionID: "t1", Amount: 36, Time: at})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(64), output.AvailableLimit)
+			assert.Equal(t, Money(36), output.refundable.Transactions()[0].Amount)
+		},
+		"Should not capture more than the hold beyond the available limit": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Capture(account, Capture{TransactionID: "t1", Amount: 101, Time: at})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(InsufficientLimit)}, errs)
+		},
+		"Should not capture transaction that is not held": func(t *testing.T) {
+			// given
+			captured := account
+			captured.refundable = NewRefundable(Transaction{ID: "t1", Amount: 30, Time: held.Time})
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			_, errs := m.Capture(captured, Capture{TransactionID: "t1", Time: at})
+
+			// then
+			assert.Equal(t, []error{errors.New(HoldNotFound)}, errs)
+		},
+		"Should not capture unknown transaction": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			_, errs := m.Capture(account, Capture{TransactionID: "t2", Time: at})
+
+			// then
+			assert.Equal(t, []error{errors.New(OriginalTransactionNotFound)}, errs)
+		},
+		"Should not capture expired hold": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db).WithHoldExpiry(24 * time.Hour)
+
+			// when
+			output, errs := m.Capture(account, Capture{TransactionID: "t1", Time: at})
+
+			// then
+			assert.Equal(t, []error{errors.New(OriginalTransactionNotFound)}, errs)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Empty(t, output.refundable.Transactions())
+			assert.Equal(t, 0, output.history.Len())
+		},
+		"Should not refund hold before it is captured": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			_, errs := m.Refund(account, Refund{TransactionID: "t1"})
+
+			// then
+			assert.Equal(t, []error{errors.New(HoldNotCaptured)}, errs)
+		},
+		"Should capture the hold even after a transaction tried to reuse its identifier": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			account, _ = m.Authorize(account, held)
+			account, reused := m.Authorize(account, Transaction{ID: "t1", Merchant: "Minibar", Amount: 10, Time: held.Time.Add(time.Hour)})
+
+			// when
+			output, errs := m.Capture(account, Capture{TransactionID: "t1", Amount: 25, Time: at})
+
+			// then
+			assert.Equal(t, []error{errors.New(DuplicateTransactionID)}, reused)
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(75), output.AvailableLimit)
+			assert.Equal(t, []Transaction{{ID: "t1", Merchant: "Grand Hotel", Amount: 25, Time: held.Time}}, output.refundable.Transactions())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestHolds(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should reserve limit for hold": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: 100}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{ID: "t1", Merchant: "Grand Hotel", Amount: 30, Time: time.Now(), Hold: true})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(70), output.AvailableLimit)
+			assert.True(t, output.refundable.Transactions()[0].Hold)
+		},
+		"Should not hold transaction without identifier": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: 100}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			_, errs := m.Authorize(account, Transaction{Merchant: "Grand Hotel", Amount: 30, Time: time.Now(), Hold: true})
+
+			// then
+			assert.Equal(t, []error{errors.New(TransactionIDRequired)}, errs)
+		},
+		"Should release expired holds before authorizing": func(t *testing.T) {
+			// given
+			held := Transaction{ID: "t1", Merchant: "Grand Hotel", Amount: 80, Time: time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC), Hold: true}
+			account := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 20,
+				history:        NewHistory(held),
+				refundable:     NewRefundable(held),
+			}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			events := NewMemoryEventStore()
+			m := NewAccountManager(db).WithEvents(events).WithHoldExpiry(7 * 24 * time.Hour)
+
+			// when
+			output, errs := m.Authorize(account, Transaction{
+				Merchant: "Acme Corporation",
+				Amount:   50,
+				Time:     time.Date(2020, 7, 8, 10, 0, 0, 0, time.UTC),
+			})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(50), output.AvailableLimit)
+			assert.Empty(t, output.refundable.Transactions())
+			recorded := events.Events()
+			assert.Equal(t, HoldExpired, recorded[0].Type)
+			assert.Equal(t, TransactionAuthorized, recorded[1].Type)
+		},
+		"Should release expired holds before decreasing the credit limit": func(t *testing.T) {
+			// given
+			held := Transaction{ID: "t1", Merchant: "Grand Hotel", Amount: 80, Time: time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC), Hold: true}
+			account := Account{
+				CardStatus:     CardActive,
+				CreditLimit:    100,
+				AvailableLimit: 20,
+				history:        NewHistory(held),
+				refundable:     NewRefundable(held),
+			}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db).WithHoldExpiry(7 * 24 * time.Hour)
+
+			// when
+			output, errs := m.Update(account, AccountUpdate{CreditLimit: 50, Time: time.Date(2020, 7, 8, 10, 0, 0, 0, time.UTC)})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(50), output.CreditLimit)
+			assert.Equal(t, Money(50), output.AvailableLimit)
+		},
+		"Should not reverse a hold that expired by the reversal time": func(t *testing.T) {
+			// given
+			held := Transaction{ID: "t1", Merchant: "Grand Hotel", Amount: 80, Time: time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC), Hold: true}
+			account := Account{
+				CardStatus:     CardActive,
+				CreditLimit:    100,
+				AvailableLimit: 20,
+				history:        NewHistory(held),
+				refundable:     NewRefundable(held),
+			}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db).WithHoldExpiry(7 * 24 * time.Hour)
+
+			// when
+			output, errs := m.Reverse(account, Reversal{TransactionID: "t1", Time: time.Date(2020, 7, 8, 10, 0, 0, 0, time.UTC)})
+
+			// then
+			assert.Equal(t, []error{errors.New(OriginalTransactionNotFound)}, errs)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestReverseTransaction(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should reverse transaction restoring the amount not yet refunded": func(t *testing.T) {
+			// given
+			account := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 80,
+				history:        NewHistory(Transaction{ID: "t1", Merchant: "Acme Corporation", Amount: 30}),
+				refundable:     NewRefundable(Transaction{ID: "t1", Merchant: "Acme Corporation", Amount: 30, refunded: 10}),
+			}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Reverse(account, Reversal{TransactionID: "t1"})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(100), output.AvailableLimit)
+			assert.Empty(t, output.refundable.Transactions())
+			assert.Equal(t, 0, output.history.Len())
+		},
+		"Should not reverse unknown transaction": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: 100}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Reverse(account, Reversal{TransactionID: "t1"})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(OriginalTransactionNotFound)}, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateCard(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should block card with reason": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: 100}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Update(account, CardOperation{Action: BlockCard, Reason: "lost card"})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, CardBlocked, output.CardStatus)
+			assert.Equal(t, "lost card", output.BlockReason)
+		},
+		"Should unblock card clearing block reason": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardBlocked, BlockReason: "lost card", AvailableLimit: 100}
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Update(account, CardOperation{Action: UnblockCard})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, CardActive, output.CardStatus)
+			assert.Empty(t, output.BlockReason)
+		},
+		"Should not block card without reason": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardActive, AvailableLimit: 100}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Update(account, CardOperation{Action: BlockCard})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(BlockReasonRequired)}, errs)
+		},
+		"Should not activate closed card": func(t *testing.T) {
+			// given
+			account := Account{CardStatus: CardClosed, AvailableLimit: 100}
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Update(account, CardOperation{Action: ActivateCard})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(CardIsClosed)}, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateLimit(t *testing.T) {
+	account := Account{
+		CardStatus:     CardActive,
+		CreditLimit:    100,
+		AvailableLimit: 40,
+		history:        NewHistory(Transaction{Merchant: "Acme Corporation", Amount: 60}),
+	}
+
+	tests := map[string]func(*testing.T){
+		"Should increase credit limit preserving consumed amount and history": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Update(account, AccountUpdate{CreditLimit: 150})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(150), output.CreditLimit)
+			assert.Equal(t, Money(90), output.AvailableLimit)
+			assert.Equal(t, account.history, output.history)
+		},
+		"Should decrease credit limit down to consumed amount": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+
+			// when
+			output, errs := m.Update(account, AccountUpdate{CreditLimit: 60})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(60), output.CreditLimit)
+			assert.Equal(t, Money(0), output.AvailableLimit)
+		},
+		"Should not decrease credit limit below consumed amount": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Update(account, AccountUpdate{CreditLimit: 59})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(CreditLimitBelowUsage)}, errs)
+		},
+		"Should not set a negative credit limit": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+
+			// when
+			output, errs := m.Update(Account{CardStatus: CardActive}, AccountUpdate{CreditLimit: -1})
+
+			// then
+			assert.Equal(t, Account{CardStatus: CardActive}, output)
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateSpendingLimits(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should enforce spending limits on the following transactions": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(1000)})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+
+			// when
+			account, errs := m.Update(account, SpendingLimitsUpdate{SpendingLimits: SpendingLimits{
+				PerTransaction: Units(100),
+				Daily:          Units(150),
+				Monthly:        Units(200),
+			}})
+			account, first := m.Authorize(account, Transaction{Merchant: "Alpha", Amount: Units(100), Time: now})
+			account, perTransaction := m.Authorize(account, Transaction{Merchant: "Beta", Amount: Units(101), Time: now.Add(10 * time.Minute)})
+			account, daily := m.Authorize(account, Transaction{Merchant: "Beta", Amount: Units(51), Time: now.Add(20 * time.Minute)})
+			account, nextDay := m.Authorize(account, Transaction{Merchant: "Beta", Amount: Units(100), Time: now.Add(24 * time.Hour)})
+			account, monthly := m.Authorize(account, Transaction{Merchant: "Gamma", Amount: Units(1), Time: now.Add(48 * time.Hour)})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Empty(t, first)
+			assert.Equal(t, []error{errors.New(TransactionLimitExceeded), errors.New(DailyLimitExceeded), errors.New(MonthlyLimitExceeded)}, perTransaction)
+			assert.Equal(t, []error{errors.New(DailyLimitExceeded)}, daily)
+			assert.Empty(t, nextDay)
+			assert.Equal(t, []error{errors.New(MonthlyLimitExceeded)}, monthly)
+			assert.Equal(t, Units(800), account.AvailableLimit)
+		},
+		"Should count transactions authorized before the limits were set": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(5000)})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+			account, _ = m.Authorize(account, Transaction{Merchant: "Alpha", Amount: Units(1000), Time: now})
+			account, _ = m.Authorize(account, Transaction{Merchant: "Beta", Amount: Units(50), Time: now.Add(time.Hour)})
+
+			// when
+			account, _ = m.Update(account, SpendingLimitsUpdate{SpendingLimits: SpendingLimits{Daily: Units(1000)}})
+			account, daily := m.Authorize(account, Transaction{Merchant: "Gamma", Amount: Units(1), Time: now.Add(2 * time.Hour)})
+			account, _ = m.Update(account, VelocityLimitsUpdate{VelocityLimits: VelocityLimits{MaxAmount: Units(1000), AmountIntervalMinutes: 24 * 60}})
+			account, _ = m.Update(account, SpendingLimitsUpdate{})
+			_, velocity := m.Authorize(account, Transaction{Merchant: "Gamma", Amount: Units(100), Time: now.Add(3 * time.Hour)})
+
+			// then
+			assert.Equal(t, []error{errors.New(DailyLimitExceeded)}, daily)
+			assert.Equal(t, []error{errors.New(HighAmountSmallInterval)}, velocity)
+		},
+		"Should count captured amounts instead of the held ones": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{
+				ID:             1,
+				CardStatus:     CardActive,
+				AvailableLimit: Units(1000),
+				SpendingLimits: &SpendingLimits{Daily: Units(100)},
+			})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+			account, _ = m.Authorize(account, Transaction{ID: "hotel", Merchant: "Hotel", Amount: Units(80), Time: now, Hold: true})
+
+			// when
+			account, before := m.Authorize(account, Transaction{Merchant: "Alpha", Amount: Units(30), Time: now.Add(time.Minute)})
+			account, _ = m.Capture(account, Capture{TransactionID: "hotel", Amount: Units(60), Time: now.Add(2 * time.Minute)})
+			_, after := m.Authorize(account, Transaction{Merchant: "Alpha", Amount: Units(30), Time: now.Add(3 * time.Minute)})
+
+			// then
+			assert.Equal(t, []error{errors.New(DailyLimitExceeded)}, before)
+			assert.Empty(t, after)
+		},
+		"Should remove every spending limit when none is informed": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+			account := Account{CardStatus: CardActive, SpendingLimits: &SpendingLimits{Daily: Units(100)}}
+
+			// when
+			output, errs := m.Update(account, SpendingLimitsUpdate{})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Nil(t, output.SpendingLimits)
+		},
+		"Should not set negative spending limits or unknown time zones": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+			account := Account{CardStatus: CardActive}
+
+			// when
+			_, negative := m.Update(account, SpendingLimitsUpdate{SpendingLimits: SpendingLimits{Monthly: -1}})
+			_, unknown := m.Update(account, SpendingLimitsUpdate{SpendingLimits: SpendingLimits{TimeZone: "Mars/Olympus_Mons"}})
+			_, initialized := m.Initialize(Account{CardStatus: CardActive, SpendingLimits: &SpendingLimits{TimeZone: "Mars/Olympus_Mons"}})
+
+			// then
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, negative)
+			assert.Equal(t, []error{errors.New(InvalidTimeZone)}, unknown)
+			assert.Equal(t, []error{errors.New(InvalidTimeZone)}, initialized)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateVelocityLimits(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should decline card testing across many small distinct merchants": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(1000)})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+
+			// when
+			account, errs := m.Update(account, VelocityLimitsUpdate{VelocityLimits: VelocityLimits{
+				MaxMerchants:            2,
+				MerchantIntervalMinutes: 10,
+			}})
+			account, first := m.Authorize(account, Transaction{Merchant: "Alpha", Amount: 1, Time: now})
+			account, second := m.Authorize(account, Transaction{Merchant: "Beta", Amount: 1, Time: now.Add(4 * time.Minute)})
+			account, third := m.Authorize(account, Transaction{Merchant: "Gamma", Amount: 1, Time: now.Add(8 * time.Minute)})
+			account, later := m.Authorize(account, Transaction{Merchant: "Gamma", Amount: 1, Time: now.Add(12 * time.Minute)})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Empty(t, first)
+			assert.Empty(t, second)
+			assert.Equal(t, []error{errors.New(ManyMerchantsSmallInterval)}, third)
+			assert.Empty(t, later)
+			assert.Equal(t, Units(1000)-3, account.AvailableLimit)
+		},
+		"Should remove every velocity limit when none is informed": func(t *testing.T) {
+			// given
+			db := NewDatabaseMock()
+			db.On("UpdateAccount", mock.AnythingOfType("Account"), mock.AnythingOfType("Event"))
+			m := NewAccountManager(db)
+			account := Account{CardStatus: CardActive, VelocityLimits: &VelocityLimits{MaxAmount: Units(500), AmountIntervalMinutes: 10}}
+
+			// when
+			output, errs := m.Update(account, VelocityLimitsUpdate{})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Nil(t, output.VelocityLimits)
+		},
+		"Should not set velocity limits without interval": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+			account := Account{CardStatus: CardActive}
+
+			// when
+			output, errs := m.Update(account, VelocityLimitsUpdate{VelocityLimits: VelocityLimits{MaxAmount: Units(500)}})
+			_, initialized := m.Initialize(Account{CardStatus: CardActive, VelocityLimits: &VelocityLimits{MaxMerchants: 3}})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(InvalidVelocityLimits)}, errs)
+			assert.Equal(t, []error{errors.New(InvalidVelocityLimits)}, initialized)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateMerchants(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should decline blocked and not allowed merchants": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(1000)})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+
+			// when
+			account, errBlock := m.Update(account, MerchantOperation{Action: BlockMerchant, Pattern: "bet*"})
+			account, blocked := m.Authorize(account, Transaction{Merchant: "BET365 LTD", Amount: Units(10), Time: now})
+			account, errAllow := m.Update(account, MerchantOperation{Action: AllowMerchant, Pattern: "Transit Authority"})
+			account, errRestrict := m.Update(account, MerchantOperation{Action: AllowListedOnly})
+			account, notAllowed := m.Authorize(account, Transaction{Merchant: "Grocery", Amount: Units(10), Time: now.Add(time.Minute)})
+			account, allowed := m.Authorize(account, Transaction{Merchant: "TRANSIT AUTHORITY", Amount: Units(10), Time: now.Add(2 * time.Minute)})
+
+			// then
+			assert.Empty(t, errBlock)
+			assert.Empty(t, errAllow)
+			assert.Empty(t, errRestrict)
+			assert.Equal(t, []error{errors.New(MerchantBlocked)}, blocked)
+			assert.Equal(t, []error{errors.New(MerchantNotAllowed)}, notAllowed)
+			assert.Empty(t, allowed)
+			assert.Equal(t, Units(990), account.AvailableLimit)
+		},
+		"Should not change the account when the operation is rejected": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewDatabaseMock())
+			account := Account{CardStatus: CardActive, Merchants: &MerchantLists{Blocked: []string{"bet*"}}}
+
+			// when
+			output, errs := m.Update(account, MerchantOperation{Action: BlockMerchant, Pattern: "BET*"})
+			_, initialized := m.Initialize(Account{CardStatus: CardActive, Merchants: &MerchantLists{Blocked: []string{"*"}}})
+
+			// then
+			assert.Equal(t, account, output)
+			assert.Equal(t, []error{errors.New(MerchantAlreadyListed)}, errs)
+			assert.Equal(t, []error{errors.New(MerchantPatternRequired)}, initialized)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateSimilarityExemptions(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should accept transit taps up to the exemption of the account": func(t *testing.T) {
+			// given
+			events := NewMemoryEventStore()
+			m := NewAccountManager(NewMemoryDB()).WithEvents(events)
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(100)})
+			now := time.Date(2020, 7, 12, 8, 0, 0, 0, time.UTC)
+			tap := Transaction{Merchant: "City Transit", Amount: Units(4)}
+
+			// when
+			account, errs := m.Update(account, SimilarityExemptionsUpdate{Exemptions: []SimilarityExemption{
+				{Merchant: "City Transit*", MaxSimilarityPerInterval: 2, IntervalMinutes: 10},
+			}})
+			tap.Time = now
+			account, first := m.Authorize(account, tap)
+			tap.Time = now.Add(time.Minute)
+			account, second := m.Authorize(account, tap)
+			tap.Time = now.Add(5 * time.Minute)
+			account, third := m.Authorize(account, tap)
+
+			// then
+			assert.Empty(t, errs)
+			assert.Empty(t, first)
+			assert.Empty(t, second)
+			assert.Equal(t, []error{errors.New(DoubledTransaction)}, third)
+			assert.Equal(t, Units(92), account.AvailableLimit)
+			assert.Equal(t, SimilarityExemptionsChanged, events.Events()[1].Type)
+		},
+		"Should remove every exemption when none is informed": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{
+				ID:                   1,
+				CardStatus:           CardActive,
+				AvailableLimit:       Units(100),
+				SimilarityExemptions: []SimilarityExemption{{Merchant: "Vending*", MaxSimilarityPerInterval: 3, IntervalMinutes: 5}},
+			})
+
+			// when
+			account, errs := m.Update(account, SimilarityExemptionsUpdate{})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Nil(t, account.SimilarityExemptions)
+		},
+		"Should not accept invalid exemptions": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB())
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(100)})
+
+			// when
+			_, created := m.Initialize(Account{
+				ID:                   2,
+				CardStatus:           CardActive,
+				SimilarityExemptions: []SimilarityExemption{{Merchant: "Vending*", MaxSimilarityPerInterval: 3}},
+			})
+			_, updated := m.Update(account, SimilarityExemptionsUpdate{Exemptions: []SimilarityExemption{
+				{MaxSimilarityPerInterval: 3, IntervalMinutes: 5},
+			}})
+
+			// then
+			assert.Equal(t, []error{errors.New(InvalidSimilarityExemption)}, created)
+			assert.Equal(t, []error{errors.New(MerchantPatternRequired)}, updated)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestUpdateCategories(t *testing.T) {
+	categories := NewCategoryRegistry()
+	categories.AddCodes("travel", 3000, 3299)
+	categories.AddCodes("travel", 7011, 7011)
+	categories.AddCodes("cash-advance", 6010, 6011)
+	categories.AddMerchant("Acme Airlines", "travel")
+
+	tests := map[string]func(*testing.T){
+		"Should enforce a travel only policy resolving categories from codes and merchants": func(t *testing.T) {
+			// given
+			events := NewMemoryEventStore()
+			m := NewAccountManager(NewMemoryDB()).WithEvents(events).WithCategories(categories)
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(5000)})
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+
+			// when
+			account, errs := m.Update(account, CategoryPolicyUpdate{CategoryPolicy: CategoryPolicy{
+				Allowed: []string{"travel"},
+				Limits:  map[string]SpendingLimits{"travel": {Monthly: Units(1000)}},
+			}})
+			account, flight := m.Authorize(account, Transaction{Merchant: "ACME AIRLINES", Amount: Units(800), Time: now})
+			account, hotel := m.Authorize(account, Transaction{Merchant: "Grand Hotel", MCC: "7011", Amount: Units(300), Time: now.Add(time.Hour)})
+			account, cash := m.Authorize(account, Transaction{Merchant: "ATM", MCC: "6011", Amount: Units(100), Time: now.Add(2 * time.Hour)})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Empty(t, flight)
+			assert.Equal(t, []error{errors.New(CategoryLimitExceeded)}, hotel)
+			assert.Equal(t, []error{errors.New(CategoryNotAllowed)}, cash)
+			assert.Equal(t, Units(4200), account.AvailableLimit)
+			assert.Equal(t, "travel", events.Events()[2].Transaction.Category)
+		},
+		"Should not trust the category informed on the transaction": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB()).WithCategories(categories)
+			account, _ := m.Initialize(Account{
+				ID:             1,
+				CardStatus:     CardActive,
+				AvailableLimit: Units(5000),
+				Categories:     &CategoryPolicy{Blocked: []string{"cash-advance"}},
+			})
+
+			// when
+			_, errs := m.Authorize(account, Transaction{Merchant: "ATM", MCC: "6010", Category: "travel", Amount: Units(100), Time: time.Now()})
+
+			// then
+			assert.Equal(t, []error{errors.New(CategoryBlocked)}, errs)
+		},
+		"Should not set policies on unknown categories nor accept malformed codes": func(t *testing.T) {
+			// given
+			m := NewAccountManager(NewMemoryDB()).WithCategories(categories)
+			account, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(5000)})
+
+			// when
+			_, unknown := m.Update(account, CategoryPolicyUpdate{CategoryPolicy: CategoryPolicy{Blocked: []string{"gambling"}}})
+			_, negative := m.Update(account, CategoryPolicyUpdate{CategoryPolicy: CategoryPolicy{Limits: map[string]SpendingLimits{"travel": {Daily: -1}}}})
+			_, malformed := m.Authorize(account, Transaction{Merchant: "Grand Hotel", MCC: "70A1", Amount: Units(100), Time: time.Now()})
+
+			// then
+			assert.Equal(t, []error{errors.New(UnknownCategory)}, unknown)
+			assert.Equal(t, []error{errors.New(InvalidAmount)}, negative)
+			assert.Equal(t, []error{errors.New(InvalidMCC)}, malformed)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestRecordEvents(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should record an event for every change and decline": func(t *testing.T) {
+			// given
+			events := NewMemoryEventStore()
+			m := NewAccountManager(NewMemoryDB()).WithEvents(events)
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+			acc, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			acc, _ = m.Authorize(acc, Transaction{ID: "t1", AccountID: 1, Merchant: "Acme Corporation", Amount: 80, Time: now})
+
+			// when
+			acc, _ = m.Authorize(acc, Transaction{ID: "t2", AccountID: 1, Merchant: "Acme Corporation", Amount: 30, Time: now})
+			acc, _ = m.Refund(acc, Refund{TransactionID: "t1", Amount: 10})
+
+			// then
+			recorded := events.Events()
+			assert.Len(t, recorded, 4)
+			assert.Equal(t, []EventType{AccountCreated, TransactionAuthorized, TransactionDeclined, TransactionRefunded},
+				[]EventType{recorded[0].Type, recorded[1].Type, recorded[2].Type, recorded[3].Type})
+			assert.Equal(t, []int{1, 2, 2, 3}, []int{recorded[0].Version, recorded[1].Version, recorded[2].Version, recorded[3].Version})
+			assert.Equal(t, []string{InsufficientLimit}, recorded[2].Violations)
+			assert.Equal(t, Money(30), acc.AvailableLimit)
+			assert.Equal(t, 3, acc.version)
+		},
+		"Should not record events for rejected operations": func(t *testing.T) {
+			// given
+			events := NewMemoryEventStore()
+			m := NewAccountManager(NewMemoryDB()).WithEvents(events)
+			acc, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+
+			// when
+			m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			m.Refund(acc, Refund{TransactionID: "unknown"})
+			m.Update(acc, CardOperation{Action: ActivateCard})
+
+			// then
+			assert.Len(t, events.Events(), 1)
+		},
+		"Should keep changes whose event is durable when the account cannot be logged": func(t *testing.T) {
+			// given
+			dir := t.TempDir()
+			events, _ := OpenFileEventStore(dir)
+			db, _ := OpenFileDB(dir)
+			m := NewAccountManager(db).WithEvents(events)
+			now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+			acc, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.wal.Close()
+
+			// when
+			acc, failed := m.Authorize(acc, Transaction{AccountID: 1, Merchant: "Acme Corporation", Amount: 20, Time: now})
+			stored, _ := db.FindAccount(1)
+			acc, next := m.Authorize(stored, Transaction{AccountID: 1, Merchant: "Grand Hotel", Amount: 30, Time: now.Add(time.Hour)})
+			events.Close()
+
+			// then
+			assert.Empty(t, failed)
+			assert.Empty(t, next)
+			assert.Equal(t, Money(50), acc.AvailableLimit)
+			reopened, _ := OpenFileEventStore(dir)
+			defer reopened.Close()
+			recovered, _ := OpenFileDB(dir)
+			defer recovered.Close()
+			assert.NoError(t, Rebuild(reopened, recovered))
+			found, _ := recovered.FindAccount(1)
+			assert.Equal(t, Money(50), found.AvailableLimit)
+			assert.Equal(t, acc.version, found.version)
+		},
 	}
 
 	for name, run := range tests {
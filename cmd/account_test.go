@@ -1,46 +1,57 @@
 package main
 
 import (
+	"encoding/json"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestCountMatches(t *testing.T) {
+func TestAccountJSON(t *testing.T) {
 	tests := map[string]func(*testing.T){
-		"Should count and group transaction matches according to interval rules": func(t *testing.T) {
+		"Should decode card status from legacy active card flag": func(t *testing.T) {
 			// given
-			account := &Account{
-				transactions: []Transaction{
-					{
-						Merchant: "Alpha",
-						Amount:   10,
-						Time:     time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC),
-					},
-					{
-						Merchant: "Beta",
-						Amount:   20,
-						Time:     time.Date(2020, 7, 12, 10, 31, 0, 0, time.UTC),
-					},
-					{
-						Merchant: "Gamma",
-						Amount:   30,
-						Time:     time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC),
-					},
-				},
-			}
+			var active, inactive Account
 
 			// when
-			matches := account.countMatches(Transaction{
-				Merchant: "Gamma",
-				Amount:   30,
-				Time:     time.Date(2020, 7, 12, 10, 32, 1, 0, time.UTC),
-			})
+			errActive := json.Unmarshal([]byte(`{ "activeCard": true, "availableLimit": 100 }`), &active)
+			errInactive := json.Unmarshal([]byte(`{ "activeCard": false, "availableLimit": 100 }`), &inactive)
 
 			// then
-			assert.Equal(t, 2, matches.frequency)
-			assert.Equal(t, 1, matches.similarity)
+			assert.NoError(t, errActive)
+			assert.Equal(t, CardActive, active.CardStatus)
+			assert.Equal(t, Units(100), active.AvailableLimit)
+			assert.NoError(t, errInactive)
+			assert.Equal(t, CardInactive, inactive.CardStatus)
+		},
+		"Should decode card status": func(t *testing.T) {
+			// given
+			var acc Account
+
+			// when
+			err := json.Unmarshal([]byte(`{ "accountId": 1, "cardStatus": "blocked", "blockReason": "lost", "availableLimit": 100 }`), &acc)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, Account{ID: 1, CardStatus: CardBlocked, BlockReason: "lost", AvailableLimit: Units(100)}, acc)
+		},
+		"Should not decode unknown card status": func(t *testing.T) {
+			// given
+			var acc Account
+
+			// when
+			err := json.Unmarshal([]byte(`{ "cardStatus": "frozen" }`), &acc)
+
+			// then
+			assert.EqualError(t, err, `unknown card status "frozen"`)
+		},
+		"Should encode card status along with active card flag": func(t *testing.T) {
+			// when
+			content, err := json.Marshal(Account{CardStatus: CardBlocked, BlockReason: "lost", AvailableLimit: Units(100)})
+
+			// then
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"activeCard":false,"cardStatus":"blocked","blockReason":"lost","availableLimit":100}`, string(content))
 		},
 	}
 
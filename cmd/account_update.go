@@ -0,0 +1,17 @@
+package main
+
+import (
+	"time"
+)
+
+// AccountUpdate changes the credit limit, releasing first the holds expired by its time, when
+// informed, so they do not count as limit in use.
+type AccountUpdate struct {
+	AccountID   int       `json:"accountId,omitempty"`
+	CreditLimit Money     `json:"creditLimit"`
+	Time        time.Time `json:"time"`
+}
+
+func (au AccountUpdate) accountID() int {
+	return au.AccountID
+}
@@ -0,0 +1,848 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: authorizer.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Violation int32
+
+const (
+	Violation_VIOLATION_UNSPECIFIED              Violation = 0
+	Violation_ACCOUNT_ALREADY_INITIALIZED        Violation = 1
+	Violation_INSUFFICIENT_LIMIT                 Violation = 2
+	Violation_CARD_NOT_ACTIVE                    Violation = 3
+	Violation_HIGH_FREQUENCY_SMALL_INTERVAL      Violation = 4
+	Violation_DOUBLED_TRANSACTION                Violation = 5
+	Violation_ACCOUNT_NOT_INITIALIZED            Violation = 6
+	Violation_CARD_BLOCKED                       Violation = 7
+	Violation_CARD_CLOSED                        Violation = 8
+	Violation_CARD_ALREADY_ACTIVE                Violation = 9
+	Violation_CARD_ALREADY_BLOCKED               Violation = 10
+	Violation_CARD_NOT_BLOCKED                   Violation = 11
+	Violation_BLOCK_REASON_REQUIRED              Violation = 12
+	Violation_INVALID_CARD_ACTION                Violation = 13
+	Violation_INVALID_CARD_STATUS                Violation = 14
+	Violation_ORIGINAL_TRANSACTION_NOT_FOUND     Violation = 15
+	Violation_REFUND_EXCEEDS_ORIGINAL            Violation = 16
+	Violation_INVALID_AMOUNT                     Violation = 17
+	Violation_CREDIT_LIMIT_BELOW_USAGE           Violation = 18
+	Violation_AVAILABLE_LIMIT_ABOVE_CREDIT_LIMIT Violation = 19
+	Violation_ACCOUNT_VERSION_CONFLICT           Violation = 20
+	Violation_STORAGE_UNAVAILABLE                Violation = 21
+	Violation_CURRENCY_MISMATCH                  Violation = 22
+	Violation_AMOUNT_OVERFLOW                    Violation = 23
+	Violation_TRANSACTION_ID_REQUIRED            Violation = 24
+	Violation_DUPLICATE_TRANSACTION_ID           Violation = 25
+	Violation_HOLD_NOT_FOUND                     Violation = 26
+	Violation_HOLD_NOT_CAPTURED                  Violation = 27
+	Violation_PARTIALLY_APPROVED                 Violation = 28
+	Violation_INVALID_TIME_ZONE                  Violation = 29
+	Violation_TRANSACTION_LIMIT_EXCEEDED         Violation = 30
+	Violation_DAILY_LIMIT_EXCEEDED               Violation = 31
+	Violation_MONTHLY_LIMIT_EXCEEDED             Violation = 32
+	Violation_INVALID_VELOCITY_LIMITS            Violation = 33
+	Violation_HIGH_AMOUNT_SMALL_INTERVAL         Violation = 34
+	Violation_MANY_MERCHANTS_SMALL_INTERVAL      Violation = 35
+	Violation_MERCHANT_BLOCKED                   Violation = 36
+	Violation_MERCHANT_NOT_ALLOWED               Violation = 37
+	Violation_MERCHANT_PATTERN_REQUIRED          Violation = 38
+	Violation_MERCHANT_ALREADY_LISTED            Violation = 39
+	Violation_MERCHANT_NOT_LISTED                Violation = 40
+	Violation_INVALID_MERCHANT_ACTION            Violation = 41
+	Violation_INVALID_MCC                        Violation = 42
+	Violation_UNKNOWN_CATEGORY                   Violation = 43
+	Violation_CATEGORY_BLOCKED                   Violation = 44
+	Violation_CATEGORY_NOT_ALLOWED               Violation = 45
+	Violation_CATEGORY_LIMIT_EXCEEDED            Violation = 46
+	Violation_INVALID_SIMILARITY_EXEMPTION       Violation = 47
+	Violation_INVALID_INPUT                      Violation = 48
+)
+
+// Enum value maps for Violation.
+var (
+	Violation_name = map[int32]string{
+		0:  "VIOLATION_UNSPECIFIED",
+		1:  "ACCOUNT_ALREADY_INITIALIZED",
+		2:  "INSUFFICIENT_LIMIT",
+		3:  "CARD_NOT_ACTIVE",
+		4:  "HIGH_FREQUENCY_SMALL_INTERVAL",
+		5:  "DOUBLED_TRANSACTION",
+		6:  "ACCOUNT_NOT_INITIALIZED",
+		7:  "CARD_BLOCKED",
+		8:  "CARD_CLOSED",
+		9:  "CARD_ALREADY_ACTIVE",
+		10: "CARD_ALREADY_BLOCKED",
+		11: "CARD_NOT_BLOCKED",
+		12: "BLOCK_REASON_REQUIRED",
+		13: "INVALID_CARD_ACTION",
+		14: "INVALID_CARD_STATUS",
+		15: "ORIGINAL_TRANSACTION_NOT_FOUND",
+		16: "REFUND_EXCEEDS_ORIGINAL",
+		17: "INVALID_AMOUNT",
+		18: "CREDIT_LIMIT_BELOW_USAGE",
+		19: "AVAILABLE_LIMIT_ABOVE_CREDIT_LIMIT",
+		20: "ACCOUNT_VERSION_CONFLICT",
+		21: "STORAGE_UNAVAILABLE",
+		22: "CURRENCY_MISMATCH",
+		23: "AMOUNT_OVERFLOW",
+		24: "TRANSACTION_ID_REQUIRED",
+		25: "DUPLICATE_TRANSACTION_ID",
+		26: "HOLD_NOT_FOUND",
+		27: "HOLD_NOT_CAPTURED",
+		28: "PARTIALLY_APPROVED",
+		29: "INVALID_TIME_ZONE",
+		30: "TRANSACTION_LIMIT_EXCEEDED",
+		31: "DAILY_LIMIT_EXCEEDED",
+		32: "MONTHLY_LIMIT_EXCEEDED",
+		33: "INVALID_VELOCITY_LIMITS",
+		34: "HIGH_AMOUNT_SMALL_INTERVAL",
+		35: "MANY_MERCHANTS_SMALL_INTERVAL",
+		36: "MERCHANT_BLOCKED",
+		37: "MERCHANT_NOT_ALLOWED",
+		38: "MERCHANT_PATTERN_REQUIRED",
+		39: "MERCHANT_ALREADY_LISTED",
+		40: "MERCHANT_NOT_LISTED",
+		41: "INVALID_MERCHANT_ACTION",
+		42: "INVALID_MCC",
+		43: "UNKNOWN_CATEGORY",
+		44: "CATEGORY_BLOCKED",
+		45: "CATEGORY_NOT_ALLOWED",
+		46: "CATEGORY_LIMIT_EXCEEDED",
+		47: "INVALID_SIMILARITY_EXEMPTION",
+		48: "INVALID_INPUT",
+	}
+	Violation_value = map[string]int32{
+		"VIOLATION_UNSPECIFIED":              0,
+		"ACCOUNT_ALREADY_INITIALIZED":        1,
+		"INSUFFICIENT_LIMIT":                 2,
+		"CARD_NOT_ACTIVE":                    3,
+		"HIGH_FREQUENCY_SMALL_INTERVAL":      4,
+		"DOUBLED_TRANSACTION":                5,
+		"ACCOUNT_NOT_INITIALIZED":            6,
+		"CARD_BLOCKED":                       7,
+		"CARD_CLOSED":                        8,
+		"CARD_ALREADY_ACTIVE":                9,
+		"CARD_ALREADY_BLOCKED":               10,
+		"CARD_NOT_BLOCKED":                   11,
+		"BLOCK_REASON_REQUIRED":              12,
+		"INVALID_CARD_ACTION":                13,
+		"INVALID_CARD_STATUS":                14,
+		"ORIGINAL_TRANSACTION_NOT_FOUND":     15,
+		"REFUND_EXCEEDS_ORIGINAL":            16,
+		"INVALID_AMOUNT":                     17,
+		"CREDIT_LIMIT_BELOW_USAGE":           18,
+		"AVAILABLE_LIMIT_ABOVE_CREDIT_LIMIT": 19,
+		"ACCOUNT_VERSION_CONFLICT":           20,
+		"STORAGE_UNAVAILABLE":                21,
+		"CURRENCY_MISMATCH":                  22,
+		"AMOUNT_OVERFLOW":                    23,
+		"TRANSACTION_ID_REQUIRED":            24,
+		"DUPLICATE_TRANSACTION_ID":           25,
+		"HOLD_NOT_FOUND":                     26,
+		"HOLD_NOT_CAPTURED":                  27,
+		"PARTIALLY_APPROVED":                 28,
+		"INVALID_TIME_ZONE":                  29,
+		"TRANSACTION_LIMIT_EXCEEDED":         30,
+		"DAILY_LIMIT_EXCEEDED":               31,
+		"MONTHLY_LIMIT_EXCEEDED":             32,
+		"INVALID_VELOCITY_LIMITS":            33,
+		"HIGH_AMOUNT_SMALL_INTERVAL":         34,
+		"MANY_MERCHANTS_SMALL_INTERVAL":      35,
+		"MERCHANT_BLOCKED":                   36,
+		"MERCHANT_NOT_ALLOWED":               37,
+		"MERCHANT_PATTERN_REQUIRED":          38,
+		"MERCHANT_ALREADY_LISTED":            39,
+		"MERCHANT_NOT_LISTED":                40,
+		"INVALID_MERCHANT_ACTION":            41,
+		"INVALID_MCC":                        42,
+		"UNKNOWN_CATEGORY":                   43,
+		"CATEGORY_BLOCKED":                   44,
+		"CATEGORY_NOT_ALLOWED":               45,
+		"CATEGORY_LIMIT_EXCEEDED":            46,
+		"INVALID_SIMILARITY_EXEMPTION":       47,
+		"INVALID_INPUT":                      48,
+	}
+)
+
+func (x Violation) Enum() *Violation {
+	p := new(Violation)
+	*p = x
+	return p
+}
+
+func (x Violation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Violation) Descriptor() protoreflect.EnumDescriptor {
+	return file_authorizer_proto_enumTypes[0].Descriptor()
+}
+
+func (Violation) Type() protoreflect.EnumType {
+	return &file_authorizer_proto_enumTypes[0]
+}
+
+func (x Violation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Violation.Descriptor instead.
+func (Violation) EnumDescriptor() ([]byte, []int) {
+	return file_authorizer_proto_rawDescGZIP(), []int{0}
+}
+
+type AccountPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActiveCard bool `protobuf:"varint,1,opt,name=active_card,json=activeCard,proto3" json:"active_card,omitempty"`
+	// whole units of the currency, rounded down in responses
+	AvailableLimit int64 `protobuf:"varint,2,opt,name=available_limit,json=availableLimit,proto3" json:"available_limit,omitempty"`
+	AccountId      int64 `protobuf:"varint,3,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// one of inactive, active, blocked or closed, taking precedence over active_card when informed
+	CardStatus string `protobuf:"bytes,4,opt,name=card_status,json=cardStatus,proto3" json:"card_status,omitempty"`
+	// exact amount in minor units, such as cents, taking precedence over available_limit when informed
+	AvailableLimitMinor int64 `protobuf:"varint,5,opt,name=available_limit_minor,json=availableLimitMinor,proto3" json:"available_limit_minor,omitempty"`
+	// ISO 4217 code, BRL when not informed
+	Currency string `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+}
+
+func (x *AccountPayload) Reset() {
+	*x = AccountPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_authorizer_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccountPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountPayload) ProtoMessage() {}
+
+func (x *AccountPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_authorizer_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountPayload.ProtoReflect.Descriptor instead.
+func (*AccountPayload) Descriptor() ([]byte, []int) {
+	return file_authorizer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AccountPayload) GetActiveCard() bool {
+	if x != nil {
+		return x.ActiveCard
+	}
+	return false
+}
+
+func (x *AccountPayload) GetAvailableLimit() int64 {
+	if x != nil {
+		return x.AvailableLimit
+	}
+	return 0
+}
+
+func (x *AccountPayload) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *AccountPayload) GetCardStatus() string {
+	if x != nil {
+		return x.CardStatus
+	}
+	return ""
+}
+
+func (x *AccountPayload) GetAvailableLimitMinor() int64 {
+	if x != nil {
+		return x.AvailableLimitMinor
+	}
+	return 0
+}
+
+func (x *AccountPayload) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type TransactionPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Merchant string `protobuf:"bytes,1,opt,name=merchant,proto3" json:"merchant,omitempty"`
+	// whole units of the currency
+	Amount         int64                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Time           *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	AccountId      int64                  `protobuf:"varint,4,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// exact amount in minor units, such as cents, taking precedence over amount when informed
+	AmountMinor int64 `protobuf:"varint,6,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"`
+	// ISO 4217 code, the account currency when not informed
+	Currency string `protobuf:"bytes,7,opt,name=currency,proto3" json:"currency,omitempty"`
+}
+
+func (x *TransactionPayload) Reset() {
+	*x = TransactionPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_authorizer_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransactionPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionPayload) ProtoMessage() {}
+
+func (x *TransactionPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_authorizer_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionPayload.ProtoReflect.Descriptor instead.
+func (*TransactionPayload) Descriptor() ([]byte, []int) {
+	return file_authorizer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TransactionPayload) GetMerchant() string {
+	if x != nil {
+		return x.Merchant
+	}
+	return ""
+}
+
+func (x *TransactionPayload) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *TransactionPayload) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *TransactionPayload) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *TransactionPayload) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *TransactionPayload) GetAmountMinor() int64 {
+	if x != nil {
+		return x.AmountMinor
+	}
+	return 0
+}
+
+func (x *TransactionPayload) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type CreateAccountRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Account *AccountPayload `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+}
+
+func (x *CreateAccountRequest) Reset() {
+	*x = CreateAccountRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_authorizer_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccountRequest) ProtoMessage() {}
+
+func (x *CreateAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_authorizer_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccountRequest.ProtoReflect.Descriptor instead.
+func (*CreateAccountRequest) Descriptor() ([]byte, []int) {
+	return file_authorizer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateAccountRequest) GetAccount() *AccountPayload {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type AuthorizeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Transaction *TransactionPayload `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+}
+
+func (x *AuthorizeRequest) Reset() {
+	*x = AuthorizeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_authorizer_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuthorizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorizeRequest) ProtoMessage() {}
+
+func (x *AuthorizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_authorizer_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorizeRequest.ProtoReflect.Descriptor instead.
+func (*AuthorizeRequest) Descriptor() ([]byte, []int) {
+	return file_authorizer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AuthorizeRequest) GetTransaction() *TransactionPayload {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+type AuthorizationResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Account    *AccountPayload `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Violations []Violation     `protobuf:"varint,2,rep,packed,name=violations,proto3,enum=authorizer.Violation" json:"violations,omitempty"`
+	// every violation code, including custom ones that have no Violation counterpart
+	ViolationCodes []string `protobuf:"bytes,3,rep,name=violation_codes,json=violationCodes,proto3" json:"violation_codes,omitempty"`
+}
+
+func (x *AuthorizationResponse) Reset() {
+	*x = AuthorizationResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_authorizer_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuthorizationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorizationResponse) ProtoMessage() {}
+
+func (x *AuthorizationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_authorizer_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorizationResponse.ProtoReflect.Descriptor instead.
+func (*AuthorizationResponse) Descriptor() ([]byte, []int) {
+	return file_authorizer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AuthorizationResponse) GetAccount() *AccountPayload {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+func (x *AuthorizationResponse) GetViolations() []Violation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+func (x *AuthorizationResponse) GetViolationCodes() []string {
+	if x != nil {
+		return x.ViolationCodes
+	}
+	return nil
+}
+
+var File_authorizer_proto protoreflect.FileDescriptor
+
+var file_authorizer_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0a, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x1a, 0x1f,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xea, 0x01, 0x0a, 0x0e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x63, 0x61, 0x72,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x43,
+	0x61, 0x72, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65,
+	0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x61, 0x76,
+	0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x1d, 0x0a, 0x0a,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x63,
+	0x61, 0x72, 0x64, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x63, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x32, 0x0a, 0x15,
+	0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f,
+	0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x61, 0x76, 0x61,
+	0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4d, 0x69, 0x6e, 0x6f, 0x72,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x22, 0xff, 0x01, 0x0a,
+	0x12, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6d, 0x65, 0x72, 0x63, 0x68, 0x61, 0x6e, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x64, 0x65, 0x6d, 0x70, 0x6f,
+	0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x69, 0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4b, 0x65, 0x79, 0x12,
+	0x21, 0x0a, 0x0c, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x4d, 0x69, 0x6e,
+	0x6f, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x22, 0x4c,
+	0x0a, 0x14, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x34, 0x0a, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72,
+	0x69, 0x7a, 0x65, 0x72, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x50, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x52, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x54, 0x0a, 0x10,
+	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x40, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x65, 0x72, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0xad, 0x01, 0x0a, 0x15, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x07,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x35, 0x0a, 0x0a, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69,
+	0x7a, 0x65, 0x72, 0x2e, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x76,
+	0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x76, 0x69, 0x6f,
+	0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x0e, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x64,
+	0x65, 0x73, 0x2a, 0x9a, 0x0a, 0x0a, 0x09, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x19, 0x0a, 0x15, 0x56, 0x49, 0x4f, 0x4c, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1f, 0x0a, 0x1b, 0x41,
+	0x43, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x5f, 0x41, 0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x49,
+	0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x49, 0x5a, 0x45, 0x44, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12,
+	0x49, 0x4e, 0x53, 0x55, 0x46, 0x46, 0x49, 0x43, 0x49, 0x45, 0x4e, 0x54, 0x5f, 0x4c, 0x49, 0x4d,
+	0x49, 0x54, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x4e, 0x4f, 0x54,
+	0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x03, 0x12, 0x21, 0x0a, 0x1d, 0x48, 0x49, 0x47,
+	0x48, 0x5f, 0x46, 0x52, 0x45, 0x51, 0x55, 0x45, 0x4e, 0x43, 0x59, 0x5f, 0x53, 0x4d, 0x41, 0x4c,
+	0x4c, 0x5f, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x56, 0x41, 0x4c, 0x10, 0x04, 0x12, 0x17, 0x0a, 0x13,
+	0x44, 0x4f, 0x55, 0x42, 0x4c, 0x45, 0x44, 0x5f, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x41, 0x43, 0x54,
+	0x49, 0x4f, 0x4e, 0x10, 0x05, 0x12, 0x1b, 0x0a, 0x17, 0x41, 0x43, 0x43, 0x4f, 0x55, 0x4e, 0x54,
+	0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x49, 0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x49, 0x5a, 0x45, 0x44,
+	0x10, 0x06, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x42, 0x4c, 0x4f, 0x43, 0x4b,
+	0x45, 0x44, 0x10, 0x07, 0x12, 0x0f, 0x0a, 0x0b, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x43, 0x4c, 0x4f,
+	0x53, 0x45, 0x44, 0x10, 0x08, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x41, 0x4c,
+	0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x09, 0x12, 0x18,
+	0x0a, 0x14, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x41, 0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x42,
+	0x4c, 0x4f, 0x43, 0x4b, 0x45, 0x44, 0x10, 0x0a, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x52, 0x44,
+	0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x45, 0x44, 0x10, 0x0b, 0x12, 0x19,
+	0x0a, 0x15, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x5f, 0x52,
+	0x45, 0x51, 0x55, 0x49, 0x52, 0x45, 0x44, 0x10, 0x0c, 0x12, 0x17, 0x0a, 0x13, 0x49, 0x4e, 0x56,
+	0x41, 0x4c, 0x49, 0x44, 0x5f, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e,
+	0x10, 0x0d, 0x12, 0x17, 0x0a, 0x13, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x43, 0x41,
+	0x52, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x10, 0x0e, 0x12, 0x22, 0x0a, 0x1e, 0x4f,
+	0x52, 0x49, 0x47, 0x49, 0x4e, 0x41, 0x4c, 0x5f, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x41, 0x43, 0x54,
+	0x49, 0x4f, 0x4e, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x0f, 0x12,
+	0x1b, 0x0a, 0x17, 0x52, 0x45, 0x46, 0x55, 0x4e, 0x44, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44,
+	0x53, 0x5f, 0x4f, 0x52, 0x49, 0x47, 0x49, 0x4e, 0x41, 0x4c, 0x10, 0x10, 0x12, 0x12, 0x0a, 0x0e,
+	0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x41, 0x4d, 0x4f, 0x55, 0x4e, 0x54, 0x10, 0x11,
+	0x12, 0x1c, 0x0a, 0x18, 0x43, 0x52, 0x45, 0x44, 0x49, 0x54, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54,
+	0x5f, 0x42, 0x45, 0x4c, 0x4f, 0x57, 0x5f, 0x55, 0x53, 0x41, 0x47, 0x45, 0x10, 0x12, 0x12, 0x26,
+	0x0a, 0x22, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x5f, 0x4c, 0x49, 0x4d, 0x49,
+	0x54, 0x5f, 0x41, 0x42, 0x4f, 0x56, 0x45, 0x5f, 0x43, 0x52, 0x45, 0x44, 0x49, 0x54, 0x5f, 0x4c,
+	0x49, 0x4d, 0x49, 0x54, 0x10, 0x13, 0x12, 0x1c, 0x0a, 0x18, 0x41, 0x43, 0x43, 0x4f, 0x55, 0x4e,
+	0x54, 0x5f, 0x56, 0x45, 0x52, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x4c, 0x49,
+	0x43, 0x54, 0x10, 0x14, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x54, 0x4f, 0x52, 0x41, 0x47, 0x45, 0x5f,
+	0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x15, 0x12, 0x15, 0x0a,
+	0x11, 0x43, 0x55, 0x52, 0x52, 0x45, 0x4e, 0x43, 0x59, 0x5f, 0x4d, 0x49, 0x53, 0x4d, 0x41, 0x54,
+	0x43, 0x48, 0x10, 0x16, 0x12, 0x13, 0x0a, 0x0f, 0x41, 0x4d, 0x4f, 0x55, 0x4e, 0x54, 0x5f, 0x4f,
+	0x56, 0x45, 0x52, 0x46, 0x4c, 0x4f, 0x57, 0x10, 0x17, 0x12, 0x1b, 0x0a, 0x17, 0x54, 0x52, 0x41,
+	0x4e, 0x53, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x49, 0x44, 0x5f, 0x52, 0x45, 0x51, 0x55,
+	0x49, 0x52, 0x45, 0x44, 0x10, 0x18, 0x12, 0x1c, 0x0a, 0x18, 0x44, 0x55, 0x50, 0x4c, 0x49, 0x43,
+	0x41, 0x54, 0x45, 0x5f, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
+	0x49, 0x44, 0x10, 0x19, 0x12, 0x12, 0x0a, 0x0e, 0x48, 0x4f, 0x4c, 0x44, 0x5f, 0x4e, 0x4f, 0x54,
+	0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x1a, 0x12, 0x15, 0x0a, 0x11, 0x48, 0x4f, 0x4c, 0x44,
+	0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x43, 0x41, 0x50, 0x54, 0x55, 0x52, 0x45, 0x44, 0x10, 0x1b, 0x12,
+	0x16, 0x0a, 0x12, 0x50, 0x41, 0x52, 0x54, 0x49, 0x41, 0x4c, 0x4c, 0x59, 0x5f, 0x41, 0x50, 0x50,
+	0x52, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x1c, 0x12, 0x15, 0x0a, 0x11, 0x49, 0x4e, 0x56, 0x41, 0x4c,
+	0x49, 0x44, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x5f, 0x5a, 0x4f, 0x4e, 0x45, 0x10, 0x1d, 0x12, 0x1e,
+	0x0a, 0x1a, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4c, 0x49,
+	0x4d, 0x49, 0x54, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x1e, 0x12, 0x18,
+	0x0a, 0x14, 0x44, 0x41, 0x49, 0x4c, 0x59, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x5f, 0x45, 0x58,
+	0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x1f, 0x12, 0x1a, 0x0a, 0x16, 0x4d, 0x4f, 0x4e, 0x54,
+	0x48, 0x4c, 0x59, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44,
+	0x45, 0x44, 0x10, 0x20, 0x12, 0x1b, 0x0a, 0x17, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f,
+	0x56, 0x45, 0x4c, 0x4f, 0x43, 0x49, 0x54, 0x59, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x53, 0x10,
+	0x21, 0x12, 0x1e, 0x0a, 0x1a, 0x48, 0x49, 0x47, 0x48, 0x5f, 0x41, 0x4d, 0x4f, 0x55, 0x4e, 0x54,
+	0x5f, 0x53, 0x4d, 0x41, 0x4c, 0x4c, 0x5f, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x56, 0x41, 0x4c, 0x10,
+	0x22, 0x12, 0x21, 0x0a, 0x1d, 0x4d, 0x41, 0x4e, 0x59, 0x5f, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41,
+	0x4e, 0x54, 0x53, 0x5f, 0x53, 0x4d, 0x41, 0x4c, 0x4c, 0x5f, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x56,
+	0x41, 0x4c, 0x10, 0x23, 0x12, 0x14, 0x0a, 0x10, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54,
+	0x5f, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x45, 0x44, 0x10, 0x24, 0x12, 0x18, 0x0a, 0x14, 0x4d, 0x45,
+	0x52, 0x43, 0x48, 0x41, 0x4e, 0x54, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x41, 0x4c, 0x4c, 0x4f, 0x57,
+	0x45, 0x44, 0x10, 0x25, 0x12, 0x1d, 0x0a, 0x19, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54,
+	0x5f, 0x50, 0x41, 0x54, 0x54, 0x45, 0x52, 0x4e, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x49, 0x52, 0x45,
+	0x44, 0x10, 0x26, 0x12, 0x1b, 0x0a, 0x17, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54, 0x5f,
+	0x41, 0x4c, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x4c, 0x49, 0x53, 0x54, 0x45, 0x44, 0x10, 0x27,
+	0x12, 0x17, 0x0a, 0x13, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54, 0x5f, 0x4e, 0x4f, 0x54,
+	0x5f, 0x4c, 0x49, 0x53, 0x54, 0x45, 0x44, 0x10, 0x28, 0x12, 0x1b, 0x0a, 0x17, 0x49, 0x4e, 0x56,
+	0x41, 0x4c, 0x49, 0x44, 0x5f, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54, 0x5f, 0x41, 0x43,
+	0x54, 0x49, 0x4f, 0x4e, 0x10, 0x29, 0x12, 0x0f, 0x0a, 0x0b, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49,
+	0x44, 0x5f, 0x4d, 0x43, 0x43, 0x10, 0x2a, 0x12, 0x14, 0x0a, 0x10, 0x55, 0x4e, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4e, 0x5f, 0x43, 0x41, 0x54, 0x45, 0x47, 0x4f, 0x52, 0x59, 0x10, 0x2b, 0x12, 0x14, 0x0a,
+	0x10, 0x43, 0x41, 0x54, 0x45, 0x47, 0x4f, 0x52, 0x59, 0x5f, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x45,
+	0x44, 0x10, 0x2c, 0x12, 0x18, 0x0a, 0x14, 0x43, 0x41, 0x54, 0x45, 0x47, 0x4f, 0x52, 0x59, 0x5f,
+	0x4e, 0x4f, 0x54, 0x5f, 0x41, 0x4c, 0x4c, 0x4f, 0x57, 0x45, 0x44, 0x10, 0x2d, 0x12, 0x1b, 0x0a,
+	0x17, 0x43, 0x41, 0x54, 0x45, 0x47, 0x4f, 0x52, 0x59, 0x5f, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x5f,
+	0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x2e, 0x12, 0x20, 0x0a, 0x1c, 0x49, 0x4e,
+	0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x53, 0x49, 0x4d, 0x49, 0x4c, 0x41, 0x52, 0x49, 0x54, 0x59,
+	0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x2f, 0x12, 0x11, 0x0a, 0x0d,
+	0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x49, 0x4e, 0x50, 0x55, 0x54, 0x10, 0x30, 0x32,
+	0x88, 0x02, 0x0a, 0x0a, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x12, 0x54,
+	0x0a, 0x0d, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x20, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x21, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x41,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x09, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x65, 0x12, 0x1c, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x41,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x21, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2e, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x56, 0x0a, 0x0f, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1c, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x65, 0x72, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72,
+	0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x18, 0x5a, 0x16, 0x67, 0x6f,
+	0x2d, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x65, 0x72, 0x2f, 0x63, 0x6d, 0x64, 0x3b,
+	0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_authorizer_proto_rawDescOnce sync.Once
+	file_authorizer_proto_rawDescData = file_authorizer_proto_rawDesc
+)
+
+func file_authorizer_proto_rawDescGZIP() []byte {
+	file_authorizer_proto_rawDescOnce.Do(func() {
+		file_authorizer_proto_rawDescData = protoimpl.X.CompressGZIP(file_authorizer_proto_rawDescData)
+	})
+	return file_authorizer_proto_rawDescData
+}
+
+var file_authorizer_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_authorizer_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_authorizer_proto_goTypes = []interface{}{
+	(Violation)(0),                // 0: authorizer.Violation
+	(*AccountPayload)(nil),        // 1: authorizer.AccountPayload
+	(*TransactionPayload)(nil),    // 2: authorizer.TransactionPayload
+	(*CreateAccountRequest)(nil),  // 3: authorizer.CreateAccountRequest
+	(*AuthorizeRequest)(nil),      // 4: authorizer.AuthorizeRequest
+	(*AuthorizationResponse)(nil), // 5: authorizer.AuthorizationResponse
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_authorizer_proto_depIdxs = []int32{
+	6, // 0: authorizer.TransactionPayload.time:type_name -> google.protobuf.Timestamp
+	1, // 1: authorizer.CreateAccountRequest.account:type_name -> authorizer.AccountPayload
+	2, // 2: authorizer.AuthorizeRequest.transaction:type_name -> authorizer.TransactionPayload
+	1, // 3: authorizer.AuthorizationResponse.account:type_name -> authorizer.AccountPayload
+	0, // 4: authorizer.AuthorizationResponse.violations:type_name -> authorizer.Violation
+	3, // 5: authorizer.Authorizer.CreateAccount:input_type -> authorizer.CreateAccountRequest
+	4, // 6: authorizer.Authorizer.Authorize:input_type -> authorizer.AuthorizeRequest
+	4, // 7: authorizer.Authorizer.AuthorizeStream:input_type -> authorizer.AuthorizeRequest
+	5, // 8: authorizer.Authorizer.CreateAccount:output_type -> authorizer.AuthorizationResponse
+	5, // 9: authorizer.Authorizer.Authorize:output_type -> authorizer.AuthorizationResponse
+	5, // 10: authorizer.Authorizer.AuthorizeStream:output_type -> authorizer.AuthorizationResponse
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_authorizer_proto_init() }
+func file_authorizer_proto_init() {
+	if File_authorizer_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_authorizer_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccountPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_authorizer_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransactionPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_authorizer_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateAccountRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_authorizer_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthorizeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_authorizer_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthorizationResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_authorizer_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_authorizer_proto_goTypes,
+		DependencyIndexes: file_authorizer_proto_depIdxs,
+		EnumInfos:         file_authorizer_proto_enumTypes,
+		MessageInfos:      file_authorizer_proto_msgTypes,
+	}.Build()
+	File_authorizer_proto = out.File
+	file_authorizer_proto_rawDesc = nil
+	file_authorizer_proto_goTypes = nil
+	file_authorizer_proto_depIdxs = nil
+}
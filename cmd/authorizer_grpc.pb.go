@@ -0,0 +1,216 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: authorizer.proto
+
+package main
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Authorizer_CreateAccount_FullMethodName   = "/authorizer.Authorizer/CreateAccount"
+	Authorizer_Authorize_FullMethodName       = "/authorizer.Authorizer/Authorize"
+	Authorizer_AuthorizeStream_FullMethodName = "/authorizer.Authorizer/AuthorizeStream"
+)
+
+// AuthorizerClient is the client API for Authorizer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthorizerClient interface {
+	CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*AuthorizationResponse, error)
+	Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizationResponse, error)
+	AuthorizeStream(ctx context.Context, opts ...grpc.CallOption) (Authorizer_AuthorizeStreamClient, error)
+}
+
+type authorizerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthorizerClient(cc grpc.ClientConnInterface) AuthorizerClient {
+	return &authorizerClient{cc}
+}
+
+func (c *authorizerClient) CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*AuthorizationResponse, error) {
+	out := new(AuthorizationResponse)
+	err := c.cc.Invoke(ctx, Authorizer_CreateAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorizerClient) Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizationResponse, error) {
+	out := new(AuthorizationResponse)
+	err := c.cc.Invoke(ctx, Authorizer_Authorize_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorizerClient) AuthorizeStream(ctx context.Context, opts ...grpc.CallOption) (Authorizer_AuthorizeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Authorizer_ServiceDesc.Streams[0], Authorizer_AuthorizeStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &authorizerAuthorizeStreamClient{stream}
+	return x, nil
+}
+
+type Authorizer_AuthorizeStreamClient interface {
+	Send(*AuthorizeRequest) error
+	Recv() (*AuthorizationResponse, error)
+	grpc.ClientStream
+}
+
+type authorizerAuthorizeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *authorizerAuthorizeStreamClient) Send(m *AuthorizeRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *authorizerAuthorizeStreamClient) Recv() (*AuthorizationResponse, error) {
+	m := new(AuthorizationResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AuthorizerServer is the server API for Authorizer service.
+// All implementations must embed UnimplementedAuthorizerServer
+// for forward compatibility
+type AuthorizerServer interface {
+	CreateAccount(context.Context, *CreateAccountRequest) (*AuthorizationResponse, error)
+	Authorize(context.Context, *AuthorizeRequest) (*AuthorizationResponse, error)
+	AuthorizeStream(Authorizer_AuthorizeStreamServer) error
+	mustEmbedUnimplementedAuthorizerServer()
+}
+
+// UnimplementedAuthorizerServer must be embedded to have forward compatible implementations.
+type UnimplementedAuthorizerServer struct {
+}
+
+func (UnimplementedAuthorizerServer) CreateAccount(context.Context, *CreateAccountRequest) (*AuthorizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAccount not implemented")
+}
+func (UnimplementedAuthorizerServer) Authorize(context.Context, *AuthorizeRequest) (*AuthorizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (UnimplementedAuthorizerServer) AuthorizeStream(Authorizer_AuthorizeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method AuthorizeStream not implemented")
+}
+func (UnimplementedAuthorizerServer) mustEmbedUnimplementedAuthorizerServer() {}
+
+// UnsafeAuthorizerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthorizerServer will
+// result in compilation errors.
+type UnsafeAuthorizerServer interface {
+	mustEmbedUnimplementedAuthorizerServer()
+}
+
+func RegisterAuthorizerServer(s grpc.ServiceRegistrar, srv AuthorizerServer) {
+	s.RegisterService(&Authorizer_ServiceDesc, srv)
+}
+
+func _Authorizer_CreateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizerServer).CreateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authorizer_CreateAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizerServer).CreateAccount(ctx, req.(*CreateAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authorizer_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizerServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authorizer_Authorize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizerServer).Authorize(ctx, req.(*AuthorizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authorizer_AuthorizeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AuthorizerServer).AuthorizeStream(&authorizerAuthorizeStreamServer{stream})
+}
+
+type Authorizer_AuthorizeStreamServer interface {
+	Send(*AuthorizationResponse) error
+	Recv() (*AuthorizeRequest, error)
+	grpc.ServerStream
+}
+
+type authorizerAuthorizeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *authorizerAuthorizeStreamServer) Send(m *AuthorizationResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *authorizerAuthorizeStreamServer) Recv() (*AuthorizeRequest, error) {
+	m := new(AuthorizeRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Authorizer_ServiceDesc is the grpc.ServiceDesc for Authorizer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Authorizer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "authorizer.Authorizer",
+	HandlerType: (*AuthorizerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateAccount",
+			Handler:    _Authorizer_CreateAccount_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _Authorizer_Authorize_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AuthorizeStream",
+			Handler:       _Authorizer_AuthorizeStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "authorizer.proto",
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+func NewDefaultRuleRegistry(cfg Config) *RuleRegistry {
+	r := NewRuleRegistry()
+	_ = r.Register(InsufficientLimit, InsufficientLimitOrder, RuleFunc(insufficientLimitRule))
+	_ = r.Register(CardNotActive, CardNotActiveOrder, RuleFunc(cardNotActiveRule))
+	_ = r.Register(MerchantBlocked, MerchantBlockedOrder, RuleFunc(merchantBlockedRule))
+	_ = r.Register(MerchantNotAllowed, MerchantNotAllowedOrder, RuleFunc(merchantNotAllowedRule))
+	_ = r.Register(CategoryBlocked, CategoryBlockedOrder, RuleFunc(categoryBlockedRule))
+	_ = r.Register(CategoryNotAllowed, CategoryNotAllowedOrder, RuleFunc(categoryNotAllowedRule))
+	_ = r.Register(HighFrequencySmallInterval, HighFrequencySmallIntervalOrder, highFrequencySmallIntervalRule(cfg))
+	_ = r.Register(DoubledTransaction, DoubledTransactionOrder, doubledTransactionRule(cfg))
+	_ = r.Register(TransactionLimitExceeded, TransactionLimitExceededOrder, RuleFunc(transactionLimitRule))
+	_ = r.Register(DailyLimitExceeded, DailyLimitExceededOrder, velocityRule{maxDayLength, dailyLimitRule})
+	_ = r.Register(MonthlyLimitExceeded, MonthlyLimitExceededOrder, velocityRule{maxMonthLength, monthlyLimitRule})
+	_ = r.Register(HighAmountSmallInterval, HighAmountSmallIntervalOrder, velocityRule{maxVelocityInterval, highAmountSmallIntervalRule})
+	_ = r.Register(ManyMerchantsSmallInterval, ManyMerchantsSmallIntervalOrder, velocityRule{maxVelocityInterval, manyMerchantsSmallIntervalRule})
+	_ = r.Register(CategoryLimitExceeded, CategoryLimitExceededOrder, velocityRule{maxMonthLength, categoryLimitRule})
+	return r
+}
+
+func insufficientLimitRule(acc Account, tr Transaction, _ History) []error {
+	if remaining, err := acc.AvailableLimit.Sub(tr.Amount); err != nil || remaining < 0 {
+		return []error{errors.New(InsufficientLimit)}
+	}
+	return nil
+}
+
+func cardNotActiveRule(acc Account, _ Transaction, _ History) []error {
+	switch acc.CardStatus {
+	case CardActive:
+		return nil
+	case CardBlocked:
+		return []error{errors.New(CardIsBlocked)}
+	case CardClosed:
+		return []error{errors.New(CardIsClosed)}
+	}
+	return []error{errors.New(CardNotActive)}
+}
+
+func merchantBlockedRule(acc Account, tr Transaction, _ History) []error {
+	if acc.Merchants.blocks(tr.Merchant) {
+		return []error{errors.New(MerchantBlocked)}
+	}
+	return nil
+}
+
+func merchantNotAllowedRule(acc Account, tr Transaction, _ History) []error {
+	if !acc.Merchants.allows(tr.Merchant) {
+		return []error{errors.New(MerchantNotAllowed)}
+	}
+	return nil
+}
+
+func highFrequencySmallIntervalRule(cfg Config) velocityRule {
+	return velocityRule{cfg.interval(), func(_ Account, tr Transaction, history History) []error {
+		if countMatches(history, tr, cfg.IntervalMinutes).frequency >= cfg.MaxFrequencyPerInterval {
+			return []error{errors.New(HighFrequencySmallInterval)}
+		}
+		return nil
+	}}
+}
+
+// doubledTransactionRule looks back as far as the configured interval or exemptions, or as far as
+// an exemption of any account may ask, taking the exemptions of the account before the configured
+// ones.
+func doubledTransactionRule(cfg Config) velocityRule {
+	similarity := cfg.similarity()
+	window := longestExemption(cfg.interval(), cfg.SimilarityExemptions)
+	if window < maxVelocityInterval {
+		window = maxVelocityInterval
+	}
+	return velocityRule{window, func(acc Account, tr Transaction, history History) []error {
+		maxSimilarity, interval := cfg.MaxSimilarityPerInterval, cfg.interval()
+		if exemption, found := exemptionFor(tr.Merchant, acc.SimilarityExemptions, cfg.SimilarityExemptions); found {
+			maxSimilarity, interval = exemption.MaxSimilarityPerInterval, exemption.interval()
+		}
+		if similarity.countSince(history, tr, tr.Time.Add(-interval)) >= maxSimilarity {
+			return []error{errors.New(DoubledTransaction)}
+		}
+		return nil
+	}}
+}
+
+func longestExemption(window time.Duration, exemptions []SimilarityExemption) time.Duration {
+	for _, exemption := range exemptions {
+		if exemption.interval() > window {
+			window = exemption.interval()
+		}
+	}
+	return window
+}
+
+func transactionLimitRule(acc Account, tr Transaction, _ History) []error {
+	if limits := acc.SpendingLimits; limits != nil && limits.PerTransaction > 0 && tr.Amount > limits.PerTransaction {
+		return []error{errors.New(TransactionLimitExceeded)}
+	}
+	return nil
+}
+
+func dailyLimitRule(acc Account, tr Transaction, history History) []error {
+	if limits := acc.SpendingLimits; limits != nil && limits.exceeds(limits.Daily, history, tr, calendarDay, anyTransaction) {
+		return []error{errors.New(DailyLimitExceeded)}
+	}
+	return nil
+}
+
+func monthlyLimitRule(acc Account, tr Transaction, history History) []error {
+	if limits := acc.SpendingLimits; limits != nil && limits.exceeds(limits.Monthly, history, tr, calendarMonth, anyTransaction) {
+		return []error{errors.New(MonthlyLimitExceeded)}
+	}
+	return nil
+}
+
+func categoryBlockedRule(acc Account, tr Transaction, _ History) []error {
+	if acc.Categories.blocks(tr.Category) {
+		return []error{errors.New(CategoryBlocked)}
+	}
+	return nil
+}
+
+func categoryNotAllowedRule(acc Account, tr Transaction, _ History) []error {
+	if !acc.Categories.allows(tr.Category) {
+		return []error{errors.New(CategoryNotAllowed)}
+	}
+	return nil
+}
+
+func categoryLimitRule(acc Account, tr Transaction, history History) []error {
+	if acc.Categories == nil || tr.Category == "" {
+		return nil
+	}
+	limits, found := acc.Categories.Limits[tr.Category]
+	if !found {
+		return nil
+	}
+
+	sameCategory := func(t Transaction) bool {
+		return t.Category == tr.Category
+	}
+	if (limits.PerTransaction > 0 && tr.Amount > limits.PerTransaction) ||
+		limits.exceeds(limits.Daily, history, tr, calendarDay, sameCategory) ||
+		limits.exceeds(limits.Monthly, history, tr, calendarMonth, sameCategory) {
+		return []error{errors.New(CategoryLimitExceeded)}
+	}
+	return nil
+}
+
+func highAmountSmallIntervalRule(acc Account, tr Transaction, history History) []error {
+	if limits := acc.VelocityLimits; limits != nil && limits.MaxAmount > 0 {
+		if spent, err := amountWithin(history, tr, limits.AmountIntervalMinutes); err != nil || spent > limits.MaxAmount {
+			return []error{errors.New(HighAmountSmallInterval)}
+		}
+	}
+	return nil
+}
+
+func manyMerchantsSmallIntervalRule(acc Account, tr Transaction, history History) []error {
+	if limits := acc.VelocityLimits; limits != nil && limits.MaxMerchants > 0 {
+		if merchantsWithin(history, tr, limits.MerchantIntervalMinutes) > limits.MaxMerchants {
+			return []error{errors.New(ManyMerchantsSmallInterval)}
+		}
+	}
+	return nil
+}
+
+// velocityRule looks back as far as window, which covers whatever limit any account may set, so
+// limits set or widened later still count every transaction within them.
+type velocityRule struct {
+	window time.Duration
+	RuleFunc
+}
+
+func (r velocityRule) Window() time.Duration {
+	return r.window
+}
+
+const (
+	InsufficientLimitOrder          = 10
+	CardNotActiveOrder              = 20
+	MerchantBlockedOrder            = 22
+	MerchantNotAllowedOrder         = 24
+	CategoryBlockedOrder            = 26
+	CategoryNotAllowedOrder         = 28
+	HighFrequencySmallIntervalOrder = 30
+	DoubledTransactionOrder         = 40
+	TransactionLimitExceededOrder   = 50
+	DailyLimitExceededOrder         = 60
+	MonthlyLimitExceededOrder       = 70
+	HighAmountSmallIntervalOrder    = 80
+	ManyMerchantsSmallIntervalOrder = 90
+	CategoryLimitExceededOrder      = 95
+)
+
+// The longest a calendar day or month can last, when clocks are set back for daylight saving time.
+const (
+	maxDayLength   = 25 * time.Hour
+	maxMonthLength = 31*24*time.Hour + time.Hour
+)
+
+const maxVelocityInterval = MaxVelocityIntervalMinutes * time.Minute
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRuleRegistry(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should register built-in rules in evaluation order": func(t *testing.T) {
+			// when
+			r := NewDefaultRuleRegistry(DefaultConfig())
+
+			// then
+			assert.Equal(t, []string{
+				InsufficientLimit,
+				CardNotActive,
+				MerchantBlocked,
+				MerchantNotAllowed,
+				CategoryBlocked,
+				CategoryNotAllowed,
+				HighFrequencySmallInterval,
+				DoubledTransaction,
+				TransactionLimitExceeded,
+				DailyLimitExceeded,
+				MonthlyLimitExceeded,
+				HighAmountSmallInterval,
+				ManyMerchantsSmallInterval,
+				CategoryLimitExceeded,
+			}, r.Names())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	history := NewHistory(
+		Transaction{Merchant: "Alpha", Amount: 10, Time: time.Date(2020, 7, 12, 10, 30, 0, 0, time.UTC)},
+		Transaction{Merchant: "Beta", Amount: 20, Time: time.Date(2020, 7, 12, 10, 31, 0, 0, time.UTC)},
+		Transaction{Merchant: "Gamma", Amount: 30, Time: time.Date(2020, 7, 12, 10, 31, 30, 0, time.UTC)},
+	)
+
+	tests := map[string]func(*testing.T){
+		"Should detect insufficient limit": func(t *testing.T) {
+			// when
+			errs := insufficientLimitRule(Account{AvailableLimit: 10}, Transaction{Amount: 11}, History{})
+
+			// then
+			assert.Equal(t, []error{errors.New(InsufficientLimit)}, errs)
+		},
+		"Should accept transaction within limit": func(t *testing.T) {
+			// when
+			errs := insufficientLimitRule(Account{AvailableLimit: 10}, Transaction{Amount: 10}, History{})
+
+			// then
+			assert.Empty(t, errs)
+		},
+		"Should detect card not active": func(t *testing.T) {
+			// when
+			errs := cardNotActiveRule(Account{CardStatus: CardInactive}, Transaction{}, History{})
+
+			// then
+			assert.Equal(t, []error{errors.New(CardNotActive)}, errs)
+		},
+		"Should detect blocked and closed cards": func(t *testing.T) {
+			// when
+			blocked := cardNotActiveRule(Account{CardStatus: CardBlocked}, Transaction{}, History{})
+			closed := cardNotActiveRule(Account{CardStatus: CardClosed}, Transaction{}, History{})
+
+			// then
+			assert.Equal(t, []error{errors.New(CardIsBlocked)}, blocked)
+			assert.Equal(t, []error{errors.New(CardIsClosed)}, closed)
+		},
+		"Should detect high frequency on small interval": func(t *testing.T) {
+			// when
+			errs := highFrequencySmallIntervalRule(DefaultConfig()).Evaluate(Account{}, Transaction{
+				Merchant: "Delta",
+				Amount:   40,
+				Time:     time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC),
+			}, history)
+
+			// then
+			assert.Equal(t, []error{errors.New(HighFrequencySmallInterval)}, errs)
+		},
+		"Should detect doubled transaction": func(t *testing.T) {
+			// when
+			errs := doubledTransactionRule(DefaultConfig()).Evaluate(Account{}, Transaction{
+				Merchant: "Beta",
+				Amount:   20,
+				Time:     time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC),
+			}, history)
+
+			// then
+			assert.Equal(t, []error{errors.New(DoubledTransaction)}, errs)
+		},
+		"Should accept doubled transaction outside of configured interval": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.IntervalMinutes = 1
+
+			// when
+			errs := doubledTransactionRule(cfg).Evaluate(Account{}, Transaction{
+				Merchant: "Alpha",
+				Amount:   10,
+				Time:     time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC),
+			}, history)
+
+			// then
+			assert.Empty(t, errs)
+		},
+		"Should detect doubled transaction informed with another descriptor and a close amount": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.MerchantMatching = NormalizedMerchantMatching
+			cfg.SimilarAmountTolerancePercent = 10
+			tr := Transaction{
+				Merchant: "BETA INC*0042",
+				Amount:   21,
+				Time:     time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC),
+			}
+
+			// when
+			errs := doubledTransactionRule(cfg).Evaluate(Account{}, tr, history)
+			exact := doubledTransactionRule(DefaultConfig()).Evaluate(Account{}, tr, history)
+
+			// then
+			assert.Equal(t, []error{errors.New(DoubledTransaction)}, errs)
+			assert.Empty(t, exact)
+		},
+		"Should accept more transactions with a higher configured frequency": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.MaxFrequencyPerInterval = 4
+
+			// when
+			errs := highFrequencySmallIntervalRule(cfg).Evaluate(Account{}, Transaction{
+				Merchant: "Delta",
+				Amount:   40,
+				Time:     time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC),
+			}, history)
+
+			// then
+			assert.Empty(t, errs)
+		},
+		"Should look back as far as the configured interval": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.IntervalMinutes = 5
+
+			// then
+			assert.Equal(t, 5*time.Minute, highFrequencySmallIntervalRule(cfg).Window())
+		},
+		"Should look back as far as similarity exemptions may ask": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			exempted := DefaultConfig()
+			exempted.SimilarityExemptions = []SimilarityExemption{{Merchant: "Beta", MaxSimilarityPerInterval: 3, IntervalMinutes: 2 * MaxVelocityIntervalMinutes}}
+
+			// then
+			assert.Equal(t, maxVelocityInterval, doubledTransactionRule(cfg).Window())
+			assert.Equal(t, 2*maxVelocityInterval, doubledTransactionRule(exempted).Window())
+		},
+		"Should accept repeated transactions on exempted merchants up to their own limit": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.SimilarityExemptions = []SimilarityExemption{{Merchant: "Beta", MaxSimilarityPerInterval: 3, IntervalMinutes: 1}}
+			acc := Account{SimilarityExemptions: []SimilarityExemption{{Merchant: "Alpha", MaxSimilarityPerInterval: 2, IntervalMinutes: 5}}}
+			now := time.Date(2020, 7, 12, 10, 32, 0, 0, time.UTC)
+
+			// when
+			beta := doubledTransactionRule(cfg).Evaluate(Account{}, Transaction{Merchant: "Beta", Amount: 20, Time: now}, history)
+			alpha := doubledTransactionRule(cfg).Evaluate(acc, Transaction{Merchant: "Alpha", Amount: 10, Time: now}, history)
+			notExempted := doubledTransactionRule(cfg).Evaluate(Account{}, Transaction{Merchant: "Alpha", Amount: 10, Time: now}, history)
+
+			// then
+			assert.Empty(t, beta)
+			assert.Empty(t, alpha)
+			assert.Equal(t, []error{errors.New(DoubledTransaction)}, notExempted)
+		},
+		"Should detect repeated transactions on exempted merchants above their own limit": func(t *testing.T) {
+			// given
+			acc := Account{SimilarityExemptions: []SimilarityExemption{{Merchant: "Alpha", MaxSimilarityPerInterval: 1, IntervalMinutes: 5}}}
+
+			// when
+			errs := doubledTransactionRule(DefaultConfig()).Evaluate(acc, Transaction{
+				Merchant: "Alpha",
+				Amount:   10,
+				Time:     time.Date(2020, 7, 12, 10, 34, 0, 0, time.UTC),
+			}, history)
+
+			// then
+			assert.Equal(t, []error{errors.New(DoubledTransaction)}, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"time"
+)
+
+// Capture settles a hold. The amount defaults to the held one and may be lower, releasing the rest,
+// or higher, such as when a tip is added.
+type Capture struct {
+	AccountID     int       `json:"accountId,omitempty"`
+	TransactionID string    `json:"transactionId"`
+	Amount        Money     `json:"amount,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+func (cp Capture) accountID() int {
+	return cp.AccountID
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+)
+
+type CardStatus string
+
+const (
+	CardInactive CardStatus = "inactive"
+	CardActive   CardStatus = "active"
+	CardBlocked  CardStatus = "blocked"
+	CardClosed   CardStatus = "closed"
+)
+
+type CardAction string
+
+const (
+	ActivateCard CardAction = "activate"
+	BlockCard    CardAction = "block"
+	UnblockCard  CardAction = "unblock"
+	CloseCard    CardAction = "close"
+)
+
+type CardOperation struct {
+	AccountID int        `json:"accountId,omitempty"`
+	Action    CardAction `json:"action"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+func (op CardOperation) accountID() int {
+	return op.AccountID
+}
+
+func (s CardStatus) isValid() bool {
+	switch s {
+	case CardInactive, CardActive, CardBlocked, CardClosed:
+		return true
+	}
+	return false
+}
+
+func (s CardStatus) transition(action CardAction) (CardStatus, error) {
+	if s == CardClosed {
+		return s, errors.New(CardIsClosed)
+	}
+
+	switch action {
+	case ActivateCard:
+		switch s {
+		case CardInactive:
+			return CardActive, nil
+		case CardActive:
+			return s, errors.New(CardAlreadyActive)
+		default:
+			return s, errors.New(CardIsBlocked)
+		}
+	case BlockCard:
+		switch s {
+		case CardActive:
+			return CardBlocked, nil
+		case CardBlocked:
+			return s, errors.New(CardAlreadyBlocked)
+		default:
+			return s, errors.New(CardNotActive)
+		}
+	case UnblockCard:
+		if s != CardBlocked {
+			return s, errors.New(CardNotBlocked)
+		}
+		return CardActive, nil
+	case CloseCard:
+		return CardClosed, nil
+	}
+	return s, errors.New(InvalidCardAction)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardStatusTransition(t *testing.T) {
+	type transition struct {
+		from   CardStatus
+		action CardAction
+		to     CardStatus
+		err    error
+	}
+
+	tests := map[string]func(*testing.T){
+		"Should follow card lifecycle": func(t *testing.T) {
+			transitions := []transition{
+				{CardInactive, ActivateCard, CardActive, nil},
+				{CardActive, BlockCard, CardBlocked, nil},
+				{CardBlocked, UnblockCard, CardActive, nil},
+				{CardInactive, CloseCard, CardClosed, nil},
+				{CardActive, CloseCard, CardClosed, nil},
+				{CardBlocked, CloseCard, CardClosed, nil},
+			}
+			for _, tr := range transitions {
+				status, err := tr.from.transition(tr.action)
+				assert.NoError(t, err, "%s -> %s", tr.from, tr.action)
+				assert.Equal(t, tr.to, status, "%s -> %s", tr.from, tr.action)
+			}
+		},
+		"Should reject invalid transitions": func(t *testing.T) {
+			transitions := []transition{
+				{CardActive, ActivateCard, CardActive, errors.New(CardAlreadyActive)},
+				{CardBlocked, ActivateCard, CardBlocked, errors.New(CardIsBlocked)},
+				{CardInactive, BlockCard, CardInactive, errors.New(CardNotActive)},
+				{CardBlocked, BlockCard, CardBlocked, errors.New(CardAlreadyBlocked)},
+				{CardActive, UnblockCard, CardActive, errors.New(CardNotBlocked)},
+				{CardInactive, UnblockCard, CardInactive, errors.New(CardNotBlocked)},
+				{CardClosed, ActivateCard, CardClosed, errors.New(CardIsClosed)},
+				{CardClosed, UnblockCard, CardClosed, errors.New(CardIsClosed)},
+				{CardClosed, CloseCard, CardClosed, errors.New(CardIsClosed)},
+				{CardActive, CardAction("freeze"), CardActive, errors.New(InvalidCardAction)},
+			}
+			for _, tr := range transitions {
+				status, err := tr.from.transition(tr.action)
+				assert.Equal(t, tr.err, err, "%s -> %s", tr.from, tr.action)
+				assert.Equal(t, tr.to, status, "%s -> %s", tr.from, tr.action)
+			}
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
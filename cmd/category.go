@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CategoryRegistry groups merchant category codes into categories, such as travel or cash
+// advances, and knows the category of merchants that do not inform their code.
+type CategoryRegistry struct {
+	codes     []categoryCodes
+	merchants map[string]string
+}
+
+type categoryCodes struct {
+	category string
+	from     int
+	to       int
+}
+
+func NewCategoryRegistry() *CategoryRegistry {
+	return &CategoryRegistry{merchants: map[string]string{}}
+}
+
+// AddCodes files the merchant category codes from and to, both inclusive, under the category.
+func (r *CategoryRegistry) AddCodes(category string, from int, to int) {
+	r.codes = append(r.codes, categoryCodes{category, from, to})
+}
+
+// AddMerchant files the merchant under the category, matching its name like merchant patterns do.
+func (r *CategoryRegistry) AddMerchant(merchant string, category string) {
+	r.merchants[normalizeMerchant(merchant)] = category
+}
+
+// Knows reports whether the category has any code or merchant filed under it.
+func (r *CategoryRegistry) Knows(category string) bool {
+	for _, codes := range r.codes {
+		if codes.category == category {
+			return true
+		}
+	}
+	for _, known := range r.merchants {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Categorize returns the category of the merchant category code informed by the transaction,
+// falling back to the category of its merchant, or an empty category when neither is known.
+func (r *CategoryRegistry) Categorize(tr Transaction) string {
+	if code, err := strconv.Atoi(tr.MCC); err == nil {
+		for _, codes := range r.codes {
+			if code >= codes.from && code <= codes.to {
+				return codes.category
+			}
+		}
+	}
+	return r.merchants[normalizeMerchant(tr.Merchant)]
+}
+
+// CategoryPolicy restricts what an account can spend on each category. When Allowed is set, only
+// transactions in those categories are accepted, and Limits caps the spending per category.
+type CategoryPolicy struct {
+	Blocked []string                  `json:"blocked,omitempty"`
+	Allowed []string                  `json:"allowed,omitempty"`
+	Limits  map[string]SpendingLimits `json:"limits,omitempty"`
+}
+
+// CategoryPolicyUpdate replaces the category policy of an account.
+type CategoryPolicyUpdate struct {
+	AccountID int `json:"accountId,omitempty"`
+	CategoryPolicy
+}
+
+func (cu CategoryPolicyUpdate) accountID() int {
+	return cu.AccountID
+}
+
+func (p CategoryPolicy) isEmpty() bool {
+	return len(p.Blocked) == 0 && len(p.Allowed) == 0 && len(p.Limits) == 0
+}
+
+func (p CategoryPolicy) validate(registry *CategoryRegistry) error {
+	categories := append(append([]string{}, p.Blocked...), p.Allowed...)
+	for category, limits := range p.Limits {
+		if err := limits.validate(); err != nil {
+			return err
+		}
+		categories = append(categories, category)
+	}
+	for _, category := range categories {
+		if !registry.Knows(category) {
+			return errors.New(UnknownCategory)
+		}
+	}
+	return nil
+}
+
+func (p *CategoryPolicy) blocks(category string) bool {
+	return p != nil && category != "" && containsString(p.Blocked, category)
+}
+
+func (p *CategoryPolicy) allows(category string) bool {
+	return p == nil || len(p.Allowed) == 0 || containsString(p.Allowed, category)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func LoadCategoryRegistry(path string) (*CategoryRegistry, error) {
+	type category struct {
+		Name  string   `yaml:"name"`
+		Codes []string `yaml:"codes"`
+	}
+	type merchant struct {
+		Name     string `yaml:"name"`
+		Category string `yaml:"category"`
+	}
+	type document struct {
+		Categories []category `yaml:"categories"`
+		Merchants  []merchant `yaml:"merchants"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var doc document
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid merchants file %s: %w", path, err)
+	}
+
+	registry := NewCategoryRegistry()
+	for i, def := range doc.Categories {
+		if def.Name == "" {
+			return nil, fmt.Errorf("category #%d: name is required", i+1)
+		}
+		for _, codes := range def.Codes {
+			from, to, err := parseCodeRange(codes)
+			if err != nil {
+				return nil, fmt.Errorf("category %q: %w", def.Name, err)
+			}
+			registry.AddCodes(def.Name, from, to)
+		}
+	}
+	for i, def := range doc.Merchants {
+		if normalizeMerchant(def.Name) == "" || def.Category == "" {
+			return nil, fmt.Errorf("merchant #%d: name and category are required", i+1)
+		}
+		registry.AddMerchant(def.Name, def.Category)
+	}
+	return registry, nil
+}
+
+// parseCodeRange parses a single merchant category code, such as 6011, or a range, such as 3000-3299.
+func parseCodeRange(codes string) (int, int, error) {
+	bounds := strings.SplitN(codes, "-", 2)
+	from, err := parseCode(bounds[0])
+	if err != nil || len(bounds) == 1 {
+		return from, from, err
+	}
+	to, err := parseCode(bounds[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("invalid merchant category code range %q", codes)
+	}
+	return from, to, nil
+}
+
+func parseCode(code string) (int, error) {
+	code = strings.TrimSpace(code)
+	if !isMCC(code) {
+		return 0, fmt.Errorf("invalid merchant category code %q", code)
+	}
+	return strconv.Atoi(code)
+}
+
+// isMCC reports whether the code has the four digits of a merchant category code.
+func isMCC(code string) bool {
+	return len(code) == 4 && isDigits(code)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCategoryRegistry(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should load categories by code and by merchant": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+categories:
+  - name: travel
+    codes: ["3000-3299", "4511", "7011"]
+  - name: cash-advance
+    codes: ["6010", "6011"]
+merchants:
+  - { name: Acme Airlines, category: travel }
+`)
+
+			// when
+			registry, err := LoadCategoryRegistry(path)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, "travel", registry.Categorize(Transaction{MCC: "3150"}))
+			assert.Equal(t, "travel", registry.Categorize(Transaction{MCC: "7011"}))
+			assert.Equal(t, "cash-advance", registry.Categorize(Transaction{MCC: "6011", Merchant: "Acme Airlines"}))
+			assert.Equal(t, "travel", registry.Categorize(Transaction{Merchant: "ACME AIRLINES"}))
+			assert.Equal(t, "", registry.Categorize(Transaction{MCC: "5411", Merchant: "Grocery"}))
+			assert.True(t, registry.Knows("cash-advance"))
+			assert.False(t, registry.Knows("gambling"))
+		},
+		"Should not load malformed codes": func(t *testing.T) {
+			// given
+			malformed := writeRulesFile(t, `categories: [{ name: travel, codes: ["45"] }]`)
+			inverted := writeRulesFile(t, `categories: [{ name: travel, codes: ["3299-3000"] }]`)
+			unnamed := writeRulesFile(t, `merchants: [{ name: Acme Airlines }]`)
+
+			// when
+			_, errMalformed := LoadCategoryRegistry(malformed)
+			_, errInverted := LoadCategoryRegistry(inverted)
+			_, errUnnamed := LoadCategoryRegistry(unnamed)
+
+			// then
+			assert.EqualError(t, errMalformed, `category "travel": invalid merchant category code "45"`)
+			assert.EqualError(t, errInverted, `category "travel": invalid merchant category code range "3299-3000"`)
+			assert.EqualError(t, errUnnamed, "merchant #1: name and category are required")
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestCategoryRules(t *testing.T) {
+	history := NewHistory(
+		Transaction{Merchant: "Acme Airlines", Category: "travel", Amount: Units(300), Time: time.Date(2020, 7, 12, 9, 0, 0, 0, time.UTC)},
+		Transaction{Merchant: "Grocery", Amount: Units(500), Time: time.Date(2020, 7, 12, 9, 30, 0, 0, time.UTC)},
+	)
+	tr := Transaction{Merchant: "Grand Hotel", Category: "travel", Amount: Units(200), Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)}
+
+	tests := map[string]func(*testing.T){
+		"Should accept any category without policy": func(t *testing.T) {
+			// then
+			assert.Empty(t, categoryBlockedRule(Account{}, tr, history))
+			assert.Empty(t, categoryNotAllowedRule(Account{}, tr, history))
+			assert.Empty(t, categoryLimitRule(Account{}, tr, history))
+		},
+		"Should detect blocked categories": func(t *testing.T) {
+			// given
+			acc := Account{Categories: &CategoryPolicy{Blocked: []string{"cash-advance"}}}
+
+			// then
+			assert.Empty(t, categoryBlockedRule(acc, tr, history))
+			assert.Equal(t, []error{errors.New(CategoryBlocked)}, categoryBlockedRule(acc, Transaction{Category: "cash-advance"}, history))
+		},
+		"Should only accept allowed categories when any is allowed": func(t *testing.T) {
+			// given
+			acc := Account{Categories: &CategoryPolicy{Allowed: []string{"travel"}}}
+
+			// then
+			assert.Empty(t, categoryNotAllowedRule(acc, tr, history))
+			assert.Equal(t, []error{errors.New(CategoryNotAllowed)}, categoryNotAllowedRule(acc, Transaction{Merchant: "Grocery"}, history))
+		},
+		"Should sum only the amounts authorized on the same category": func(t *testing.T) {
+			// given
+			within := Account{Categories: &CategoryPolicy{Limits: map[string]SpendingLimits{"travel": {Daily: Units(500)}}}}
+			above := Account{Categories: &CategoryPolicy{Limits: map[string]SpendingLimits{"travel": {Monthly: Units(499)}}}}
+			perTransaction := Account{Categories: &CategoryPolicy{Limits: map[string]SpendingLimits{"travel": {PerTransaction: Units(199)}}}}
+
+			// then
+			assert.Empty(t, categoryLimitRule(within, tr, history))
+			assert.Equal(t, []error{errors.New(CategoryLimitExceeded)}, categoryLimitRule(above, tr, history))
+			assert.Equal(t, []error{errors.New(CategoryLimitExceeded)}, categoryLimitRule(perTransaction, tr, history))
+			assert.Empty(t, categoryLimitRule(above, Transaction{Merchant: "Grocery", Amount: Units(1000), Time: tr.Time}, history))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
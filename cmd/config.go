@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	IntervalMinutes               int                   `json:"intervalMinutes"`
+	MaxFrequencyPerInterval       int                   `json:"maxFrequencyPerInterval"`
+	MaxSimilarityPerInterval      int                   `json:"maxSimilarityPerInterval"`
+	MerchantMatching              string                `json:"merchantMatching"`
+	MerchantAliases               map[string]string     `json:"merchantAliases,omitempty"`
+	SimilarAmountTolerancePercent int                   `json:"similarAmountTolerancePercent"`
+	SimilarityExemptions          []SimilarityExemption `json:"similarityExemptions,omitempty"`
+	RulesFile                     string                `json:"rulesFile,omitempty"`
+	RatesFile                     string                `json:"ratesFile,omitempty"`
+	MerchantsFile                 string                `json:"merchantsFile,omitempty"`
+	DataDir                       string                `json:"dataDir,omitempty"`
+	Workers                       int                   `json:"workers"`
+	HoldExpiryMinutes             int                   `json:"holdExpiryMinutes"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		IntervalMinutes:          DefaultIntervalMinutes,
+		MaxFrequencyPerInterval:  DefaultMaxFrequencyPerInterval,
+		MaxSimilarityPerInterval: DefaultMaxSimilarityPerInterval,
+		MerchantMatching:         ExactMerchantMatching,
+		Workers:                  runtime.NumCPU(),
+		HoldExpiryMinutes:        DefaultHoldExpiryMinutes,
+	}
+}
+
+// LoadConfig resolves the configuration from defaults, an optional json file,
+// environment variables and command line flags, each one overriding the previous.
+// Arguments left after the flags are returned so subcommands can be parsed.
+func LoadConfig(args []string, getenv func(string) string) (Config, []string, error) {
+	cfg := DefaultConfig()
+
+	flags := flag.NewFlagSet("go-authorizer", flag.ContinueOnError)
+	path := flags.String("config", getenv(EnvConfigFile), "path to a json configuration file")
+	intervalMinutes := flags.Int("interval-minutes", 0, "velocity window in minutes")
+	maxFrequency := flags.Int("max-frequency", 0, "transactions allowed per velocity window")
+	maxSimilarity := flags.Int("max-similarity", 0, "similar transactions allowed per velocity window")
+	merchantMatching := flags.String("merchant-matching", "", "how merchants of similar transactions are compared, exact or normalized")
+	amountTolerance := flags.Int("similar-amount-tolerance-percent", 0, "percentage amounts of similar transactions may differ")
+	rulesFile := flags.String("rules", "", "path to a yaml file with declarative rules")
+	ratesFile := flags.String("rates", "", "path to a yaml file with exchange rates for foreign currencies")
+	merchantsFile := flags.String("merchants", "", "path to a yaml file with merchant categories")
+	holdExpiry := flags.Int("hold-expiry-minutes", 0, "minutes holds reserve limit before being released when not captured")
+	workers := flags.Int("workers", 0, "requests processed in parallel, one account at a time per worker")
+	dataDir := flags.String("data-dir", "", "directory where accounts are persisted, kept in memory when empty")
+	if err := flags.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	if *path != "" {
+		if err := decodeConfigFile(*path, &cfg); err != nil {
+			return cfg, nil, err
+		}
+	}
+
+	envs := map[string]*int{
+		EnvIntervalMinutes:          &cfg.IntervalMinutes,
+		EnvMaxFrequencyPerInterval:  &cfg.MaxFrequencyPerInterval,
+		EnvMaxSimilarityPerInterval: &cfg.MaxSimilarityPerInterval,
+		EnvSimilarAmountTolerance:   &cfg.SimilarAmountTolerancePercent,
+		EnvWorkers:                  &cfg.Workers,
+		EnvHoldExpiryMinutes:        &cfg.HoldExpiryMinutes,
+	}
+	for name, field := range envs {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return cfg, nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*field = parsed
+	}
+	if value := getenv(EnvMerchantMatching); value != "" {
+		cfg.MerchantMatching = value
+	}
+	if value := getenv(EnvRulesFile); value != "" {
+		cfg.RulesFile = value
+	}
+	if value := getenv(EnvRatesFile); value != "" {
+		cfg.RatesFile = value
+	}
+	if value := getenv(EnvMerchantsFile); value != "" {
+		cfg.MerchantsFile = value
+	}
+	if value := getenv(EnvDataDir); value != "" {
+		cfg.DataDir = value
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "interval-minutes":
+			cfg.IntervalMinutes = *intervalMinutes
+		case "max-frequency":
+			cfg.MaxFrequencyPerInterval = *maxFrequency
+		case "max-similarity":
+			cfg.MaxSimilarityPerInterval = *maxSimilarity
+		case "merchant-matching":
+			cfg.MerchantMatching = *merchantMatching
+		case "similar-amount-tolerance-percent":
+			cfg.SimilarAmountTolerancePercent = *amountTolerance
+		case "rules":
+			cfg.RulesFile = *rulesFile
+		case "rates":
+			cfg.RatesFile = *ratesFile
+		case "merchants":
+			cfg.MerchantsFile = *merchantsFile
+		case "workers":
+			cfg.Workers = *workers
+		case "hold-expiry-minutes":
+			cfg.HoldExpiryMinutes = *holdExpiry
+		case "data-dir":
+			cfg.DataDir = *dataDir
+		}
+	})
+
+	return cfg, flags.Args(), cfg.Validate()
+}
+
+// decodeConfigFile rejects unknown keys, so a misspelled setting is reported instead of
+// silently keeping its default.
+func decodeConfigFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c Config) Validate() error {
+	if c.IntervalMinutes <= 0 {
+		return errors.New("intervalMinutes must be greater than zero")
+	}
+	if c.MaxFrequencyPerInterval <= 0 {
+		return errors.New("maxFrequencyPerInterval must be greater than zero")
+	}
+	if c.MaxSimilarityPerInterval <= 0 {
+		return errors.New("maxSimilarityPerInterval must be greater than zero")
+	}
+	if c.MerchantMatching != ExactMerchantMatching && c.MerchantMatching != NormalizedMerchantMatching {
+		return fmt.Errorf("merchantMatching must be %s or %s, got %q", ExactMerchantMatching, NormalizedMerchantMatching, c.MerchantMatching)
+	}
+	if c.SimilarAmountTolerancePercent < 0 || c.SimilarAmountTolerancePercent > 100 {
+		return errors.New("similarAmountTolerancePercent must be between 0 and 100")
+	}
+	for i, exemption := range c.SimilarityExemptions {
+		if err := validateExemptions([]SimilarityExemption{exemption}); err != nil {
+			return fmt.Errorf("similarity exemption #%d: %w", i+1, err)
+		}
+	}
+	if c.Workers <= 0 {
+		return errors.New("workers must be greater than zero")
+	}
+	if c.HoldExpiryMinutes <= 0 {
+		return errors.New("holdExpiryMinutes must be greater than zero")
+	}
+	return nil
+}
+
+func (c Config) interval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+func (c Config) similarity() Similarity {
+	similarity := Similarity{tolerancePercent: int64(c.SimilarAmountTolerancePercent)}
+	if c.MerchantMatching == NormalizedMerchantMatching {
+		similarity.merchants = NewMerchantNormalizer(c.MerchantAliases)
+	}
+	return similarity
+}
+
+func (c Config) holdExpiry() time.Duration {
+	return time.Duration(c.HoldExpiryMinutes) * time.Minute
+}
+
+func (c Config) String() string {
+	content, _ := json.Marshal(c)
+	return string(content)
+}
+
+const (
+	DefaultIntervalMinutes          = 2
+	DefaultMaxFrequencyPerInterval  = 3
+	DefaultMaxSimilarityPerInterval = 1
+	DefaultHoldExpiryMinutes        = 7 * 24 * 60
+)
+
+const (
+	ExactMerchantMatching      = "exact"
+	NormalizedMerchantMatching = "normalized"
+)
+
+const (
+	EnvConfigFile               = "AUTHORIZER_CONFIG"
+	EnvIntervalMinutes          = "AUTHORIZER_INTERVAL_MINUTES"
+	EnvMaxFrequencyPerInterval  = "AUTHORIZER_MAX_FREQUENCY_PER_INTERVAL"
+	EnvMaxSimilarityPerInterval = "AUTHORIZER_MAX_SIMILARITY_PER_INTERVAL"
+	EnvMerchantMatching         = "AUTHORIZER_MERCHANT_MATCHING"
+	EnvSimilarAmountTolerance   = "AUTHORIZER_SIMILAR_AMOUNT_TOLERANCE_PERCENT"
+	EnvRulesFile                = "AUTHORIZER_RULES"
+	EnvRatesFile                = "AUTHORIZER_RATES"
+	EnvMerchantsFile            = "AUTHORIZER_MERCHANTS"
+	EnvDataDir                  = "AUTHORIZER_DATA_DIR"
+	EnvWorkers                  = "AUTHORIZER_WORKERS"
+	EnvHoldExpiryMinutes        = "AUTHORIZER_HOLD_EXPIRY_MINUTES"
+)
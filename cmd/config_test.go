@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	tests := map[string]func(*testing.T){
+		"Should load default configuration": func(t *testing.T) {
+			// when
+			cfg, args, err := LoadConfig(nil, noEnv)
+
+			// then
+			assert.NoError(t, err)
+			assert.Empty(t, args)
+			assert.Equal(t, Config{
+				IntervalMinutes:          2,
+				MaxFrequencyPerInterval:  3,
+				MaxSimilarityPerInterval: 1,
+				MerchantMatching:         "exact",
+				Workers:                  runtime.NumCPU(),
+				HoldExpiryMinutes:        7 * 24 * 60,
+			}, cfg)
+		},
+		"Should override configuration from file, environment and flags in order": func(t *testing.T) {
+			// given
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			_ = os.WriteFile(path, []byte(`{ "intervalMinutes": 5, "maxFrequencyPerInterval": 10, "maxSimilarityPerInterval": 2 }`), 0644)
+			env := map[string]string{
+				EnvConfigFile:              path,
+				EnvMaxFrequencyPerInterval: "20",
+				EnvDataDir:                 "/var/lib/authorizer",
+				EnvRatesFile:               "rates.yaml",
+				EnvMerchantsFile:           "merchants.yaml",
+				EnvSimilarAmountTolerance:  "5",
+			}
+
+			// when
+			cfg, args, err := LoadConfig([]string{"-max-similarity", "4", "-merchant-matching", "normalized", "-workers", "8", "-hold-expiry-minutes", "60", "-rules", "rules.yaml", "serve", "-addr", ":80"}, func(name string) string {
+				return env[name]
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"serve", "-addr", ":80"}, args)
+			assert.Equal(t, Config{
+				IntervalMinutes:               5,
+				MaxFrequencyPerInterval:       20,
+				MaxSimilarityPerInterval:      4,
+				MerchantMatching:              "normalized",
+				SimilarAmountTolerancePercent: 5,
+				RulesFile:                     "rules.yaml",
+				RatesFile:                     "rates.yaml",
+				MerchantsFile:                 "merchants.yaml",
+				DataDir:                       "/var/lib/authorizer",
+				Workers:                       8,
+				HoldExpiryMinutes:             60,
+			}, cfg)
+		},
+		"Should not load configuration with invalid environment value": func(t *testing.T) {
+			// when
+			_, _, err := LoadConfig(nil, func(name string) string {
+				if name == EnvIntervalMinutes {
+					return "two"
+				}
+				return ""
+			})
+
+			// then
+			assert.Error(t, err)
+		},
+		"Should not load configuration from missing file": func(t *testing.T) {
+			// when
+			_, _, err := LoadConfig([]string{"-config", "/does/not/exist.json"}, noEnv)
+
+			// then
+			assert.Error(t, err)
+		},
+		"Should not load invalid configuration": func(t *testing.T) {
+			// when
+			_, _, err := LoadConfig([]string{"-interval-minutes", "0"}, noEnv)
+
+			// then
+			assert.EqualError(t, err, "intervalMinutes must be greater than zero")
+		},
+		"Should not load unknown merchant matching nor tolerance out of range": func(t *testing.T) {
+			// when
+			_, _, errMatching := LoadConfig([]string{"-merchant-matching", "fuzzy"}, noEnv)
+			_, _, errTolerance := LoadConfig([]string{"-similar-amount-tolerance-percent", "101"}, noEnv)
+
+			// then
+			assert.EqualError(t, errMatching, `merchantMatching must be exact or normalized, got "fuzzy"`)
+			assert.EqualError(t, errTolerance, "similarAmountTolerancePercent must be between 0 and 100")
+		},
+		"Should not load configuration file with unknown settings": func(t *testing.T) {
+			// given
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			_ = os.WriteFile(path, []byte(`{ "intervalMinute": 5 }`), 0644)
+
+			// when
+			_, _, err := LoadConfig([]string{"-config", path}, noEnv)
+
+			// then
+			assert.EqualError(t, err, fmt.Sprintf(`invalid configuration file %s: json: unknown field "intervalMinute"`, path))
+		},
+		"Should not load invalid similarity exemptions": func(t *testing.T) {
+			// given
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			_ = os.WriteFile(path, []byte(`{ "similarityExemptions": [{ "merchant": "City Transit*", "maxSimilarityPerInterval": 2 }] }`), 0644)
+
+			// when
+			_, _, err := LoadConfig([]string{"-config", path}, noEnv)
+
+			// then
+			assert.EqualError(t, err, "similarity exemption #1: invalid-similarity-exemption")
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should accept default configuration": func(t *testing.T) {
+			// then
+			assert.NoError(t, DefaultConfig().Validate())
+		},
+		"Should reject non positive thresholds": func(t *testing.T) {
+			// given
+			frequency := DefaultConfig()
+			frequency.MaxFrequencyPerInterval = 0
+			similarity := DefaultConfig()
+			similarity.MaxSimilarityPerInterval = -1
+
+			// then
+			assert.Error(t, frequency.Validate())
+			assert.Error(t, similarity.Validate())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
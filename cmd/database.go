@@ -2,40 +2,125 @@ package main
 
 import (
 	"errors"
+	"sync"
 )
 
+// ErrVersionConflict reports that an account changed since it was read, so the
+// operation has to be evaluated again against its current state.
+var ErrVersionConflict = errors.New(AccountVersionConflict)
+
+// StorageError reports a change that could not be made durable, answered with the
+// storage-unavailable violation while Err keeps the cause.
+type StorageError struct {
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	return StorageUnavailable
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
 type DB interface {
 	CreateAccount(Account) (Account, error)
-	UpdateAccount(Account) Account
-	CurrentAccount() (Account, error)
+	// UpdateAccount replaces the account with acc, the state change produced, only while
+	// its stored version is still the one change was recorded against, failing with
+	// ErrVersionConflict otherwise.
+	UpdateAccount(acc Account, change Event) (Account, error)
+	FindAccount(id int) (Account, error)
+	SaveDecision(accountID int, key string, decision Decision) error
+	FindDecision(accountID int, key string) (Decision, error)
 }
 
+// DecisionRetention is how many idempotency decisions are kept for each account. Older ones are
+// forgotten, so a key is only replayed while it is among the latest of its account.
+const DecisionRetention = 1000
+
 type dbMemory struct {
-	account map[int]Account
+	mutex     sync.RWMutex
+	account   map[int]Account
+	decisions map[decisionKey]Decision
+	// decided keeps the keys of each account in the order their decisions were saved.
+	decided map[int][]string
+}
+
+type decisionKey struct {
+	accountID int
+	key       string
 }
 
 func NewMemoryDB() *dbMemory {
 	return &dbMemory{
-		account: map[int]Account{},
+		account:   map[int]Account{},
+		decisions: map[decisionKey]Decision{},
+		decided:   map[int][]string{},
 	}
 }
 
 func (db *dbMemory) CreateAccount(acc Account) (Account, error) {
-	if len(db.account) > 0 {
-		return db.account[0], errors.New("account already exists")
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if existing, ok := db.account[acc.ID]; ok {
+		return existing, errors.New("account already exists")
 	}
-	db.account[0] = acc
-	return db.account[0], nil
+	db.account[acc.ID] = acc
+	return db.account[acc.ID], nil
 }
 
-func (db *dbMemory) UpdateAccount(acc Account) Account {
-	db.account[0] = acc
-	return db.account[0]
+func (db *dbMemory) UpdateAccount(acc Account, change Event) (Account, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if current, ok := db.account[acc.ID]; ok && current.version != change.Version-1 {
+		return current, ErrVersionConflict
+	}
+	db.account[acc.ID] = acc
+	return acc, nil
 }
 
-func (db *dbMemory) CurrentAccount() (Account, error) {
-	if len(db.account) == 0 {
-		return Account{}, errors.New("no account set")
+// store replaces the account regardless of its version, for state that is being recovered.
+func (db *dbMemory) store(acc Account) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.account[acc.ID] = acc
+}
+
+func (db *dbMemory) FindAccount(id int) (Account, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	acc, ok := db.account[id]
+	if !ok {
+		return Account{}, errors.New("account not found")
+	}
+	return acc, nil
+}
+
+func (db *dbMemory) SaveDecision(accountID int, key string, decision Decision) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.decisions[decisionKey{accountID, key}]; !ok {
+		db.decided[accountID] = append(db.decided[accountID], key)
+	}
+	db.decisions[decisionKey{accountID, key}] = decision.response()
+
+	if keys := db.decided[accountID]; len(keys) > DecisionRetention {
+		delete(db.decisions, decisionKey{accountID, keys[0]})
+		db.decided[accountID] = keys[1:]
+	}
+	return nil
+}
+
+func (db *dbMemory) FindDecision(accountID int, key string) (Decision, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	decision, ok := db.decisions[decisionKey{accountID, key}]
+	if !ok {
+		return Decision{}, errors.New("decision not found")
 	}
-	return db.account[0], nil
+	return decision, nil
 }
@@ -20,13 +20,28 @@ func (db *dbMock) CreateAccount(acc Account) (Account, error) {
 	return res, err
 }
 
-func (db *dbMock) UpdateAccount(acc Account) Account {
-	_ = db.Called(acc)
-	return acc
+func (db *dbMock) UpdateAccount(acc Account, change Event) (Account, error) {
+	_ = db.Called(acc, change)
+	return acc, nil
 }
 
-func (db *dbMock) CurrentAccount() (Account, error) {
-	args := db.Called()
+func (db *dbMock) SaveDecision(accountID int, key string, decision Decision) error {
+	args := db.Called(accountID, key, decision)
+	if len(args) == 0 {
+		return nil
+	}
+	return args.Error(0)
+}
+
+func (db *dbMock) FindDecision(accountID int, key string) (Decision, error) {
+	args := db.Called(accountID, key)
+	res := args.Get(0).(Decision)
+	err := args.Error(1)
+	return res, err
+}
+
+func (db *dbMock) FindAccount(id int) (Account, error) {
+	args := db.Called(id)
 	res := args.Get(0).(Account)
 	err := args.Error(1)
 	return res, err
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,7 +33,7 @@ func TestCreateAccount(t *testing.T) {
 			// given
 			db := NewMemoryDB()
 			acc := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 
@@ -46,13 +48,13 @@ func TestCreateAccount(t *testing.T) {
 			// given
 			db := NewMemoryDB()
 			existing := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 			db.CreateAccount(existing)
 
 			acc := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 200,
 			}
 
@@ -63,6 +65,25 @@ func TestCreateAccount(t *testing.T) {
 			assert.Equal(t, existing, res)
 			assert.Error(t, err, "account already exists")
 		},
+		"Should create accounts with different identifiers": func(t *testing.T) {
+			// given
+			db := NewMemoryDB()
+			db.CreateAccount(Account{ID: 1, AvailableLimit: 100})
+
+			acc := Account{
+				ID:             2,
+				CardStatus:     CardActive,
+				AvailableLimit: 200,
+			}
+
+			// when
+			res, err := db.CreateAccount(acc)
+
+			// then
+			assert.Equal(t, acc, res)
+			assert.NoError(t, err)
+			assert.Len(t, db.account, 2)
+		},
 	}
 
 	for name, run := range tests {
@@ -78,21 +99,45 @@ func TestUpdateAccount(t *testing.T) {
 			// given
 			db := NewMemoryDB()
 			existing := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 			db.CreateAccount(existing)
 
 			acc := Account{
-				ActiveCard:     false,
+				CardStatus:     CardInactive,
 				AvailableLimit: 200,
 			}
 
 			// when
-			res := db.UpdateAccount(acc)
+			res, err := db.UpdateAccount(acc, Event{Type: CardStatusChanged, Version: 1})
 
 			// then
 			assert.Equal(t, acc, res)
+			assert.NoError(t, err)
+		},
+		"Should not update an account changed since it was read": func(t *testing.T) {
+			// given
+			db := NewMemoryDB()
+			current := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 80,
+				version:        2,
+			}
+			db.CreateAccount(current)
+
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 90,
+				version:        2,
+			}
+
+			// when
+			res, err := db.UpdateAccount(acc, Event{Type: CreditLimitChanged, Version: 2})
+
+			// then
+			assert.Equal(t, current, res)
+			assert.Equal(t, ErrVersionConflict, err)
 		},
 	}
 
@@ -103,34 +148,108 @@ func TestUpdateAccount(t *testing.T) {
 	}
 }
 
-func TestCurrentAccount(t *testing.T) {
+func TestFindAccount(t *testing.T) {
 	tests := map[string]func(*testing.T){
-		"Should get current account": func(t *testing.T) {
+		"Should find account by identifier": func(t *testing.T) {
 			// given
 			db := NewMemoryDB()
 			existing := Account{
-				ActiveCard:     true,
+				ID:             2,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
+			db.CreateAccount(Account{ID: 1})
 			db.CreateAccount(existing)
 
 			// when
-			res, err := db.CurrentAccount()
+			res, err := db.FindAccount(2)
 
 			// then
 			assert.Equal(t, existing, res)
 			assert.NoError(t, err)
 		},
-		"Should not get current account because it does not exists": func(t *testing.T) {
+		"Should not find account because it does not exists": func(t *testing.T) {
+			// given
+			db := NewMemoryDB()
+
+			// when
+			res, err := db.FindAccount(1)
+
+			// then
+			assert.Empty(t, res)
+			assert.Error(t, err, "account not found")
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should find saved decision by account and key": func(t *testing.T) {
+			// given
+			db := NewMemoryDB()
+			decision := Decision{
+				Account: Account{ID: 1, CardStatus: CardActive, AvailableLimit: 80},
+				Errors:  []error{errors.New(DoubledTransaction)},
+			}
+			db.SaveDecision(1, "key", decision)
+
+			// when
+			res, err := db.FindDecision(1, "key")
+
+			// then
+			assert.Equal(t, decision, res)
+			assert.NoError(t, err)
+		},
+		"Should not find decision saved for another account": func(t *testing.T) {
 			// given
 			db := NewMemoryDB()
+			db.SaveDecision(1, "key", Decision{})
 
 			// when
-			res, err := db.CurrentAccount()
+			res, err := db.FindDecision(2, "key")
 
 			// then
 			assert.Empty(t, res)
-			assert.Error(t, err, "no account set")
+			assert.Error(t, err, "decision not found")
+		},
+		"Should only keep the response of the decision": func(t *testing.T) {
+			// given
+			db := NewMemoryDB()
+			acc := Account{ID: 1, CardStatus: CardActive, AvailableLimit: 80, version: 2}
+			acc.history = NewHistory(Transaction{ID: "t1", Merchant: "Acme Corporation", Amount: 20})
+			acc.refundable = NewRefundable(Transaction{ID: "t1", Merchant: "Acme Corporation", Amount: 20})
+			db.SaveDecision(1, "key", Decision{Account: acc})
+
+			// when
+			res, _ := db.FindDecision(1, "key")
+
+			// then
+			assert.Equal(t, Account{ID: 1, CardStatus: CardActive, AvailableLimit: 80}, res.Account)
+		},
+		"Should forget the oldest decisions of an account beyond the retention": func(t *testing.T) {
+			// given
+			db := NewMemoryDB()
+			db.SaveDecision(2, "key-0", Decision{})
+			for i := 0; i <= DecisionRetention; i++ {
+				db.SaveDecision(1, fmt.Sprintf("key-%d", i), Decision{})
+			}
+
+			// when
+			_, oldest := db.FindDecision(1, "key-0")
+			_, latest := db.FindDecision(1, fmt.Sprintf("key-%d", DecisionRetention))
+			_, other := db.FindDecision(2, "key-0")
+
+			// then
+			assert.Error(t, oldest)
+			assert.NoError(t, latest)
+			assert.NoError(t, other)
+			assert.Len(t, db.decisions, DecisionRetention+1)
 		},
 	}
 
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type DeclarativeRule struct {
+	Name      string
+	Order     int
+	Violation string
+	When      *Expression
+}
+
+func (r DeclarativeRule) Evaluate(acc Account, tr Transaction, history History) []error {
+	if r.When.Matches(acc, tr, history) {
+		return []error{errors.New(r.Violation)}
+	}
+	return nil
+}
+
+func (r DeclarativeRule) Window() time.Duration {
+	return r.When.Window()
+}
+
+func LoadDeclarativeRules(path string) ([]DeclarativeRule, error) {
+	type definition struct {
+		Name      string `yaml:"name"`
+		Order     int    `yaml:"order"`
+		When      string `yaml:"when"`
+		Violation string `yaml:"violation"`
+	}
+	type document struct {
+		Rules []definition `yaml:"rules"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var doc document
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+
+	var rules []DeclarativeRule
+	for i, def := range doc.Rules {
+		if def.Name == "" {
+			return nil, fmt.Errorf("rule #%d: name is required", i+1)
+		}
+		if !violationPattern.MatchString(def.Violation) {
+			return nil, fmt.Errorf("rule %s: violation must be a kebab-case code, got %q", def.Name, def.Violation)
+		}
+		when, err := ParseExpression(def.When)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", def.Name, err)
+		}
+		if def.Order == 0 {
+			def.Order = DeclarativeRuleOrder
+		}
+		rules = append(rules, DeclarativeRule{
+			Name:      def.Name,
+			Order:     def.Order,
+			Violation: def.Violation,
+			When:      when,
+		})
+	}
+	return rules, nil
+}
+
+func RegisterDeclarativeRules(registry *RuleRegistry, rules []DeclarativeRule) error {
+	for _, rule := range rules {
+		if err := registry.Register(rule.Name, rule.Order, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var violationPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+const (
+	DeclarativeRuleOrder = 100
+)
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDeclarativeRules(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should reject rules with invalid expressions": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+rules:
+  - name: night-casino
+    when: hour(time) < 6 && contains(merchant, "casino")
+    violation: night-gambling
+  - name: big-spender
+    order: 5
+    when: sum(10m) + amount > 500
+    violation: amount-velocity
+`)
+
+			// when
+			_, err := LoadDeclarativeRules(path)
+
+			// then
+			assert.EqualError(t, err, `rule big-spender: unexpected character '+' at position 9`)
+		},
+		"Should load rules from yaml file": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+rules:
+  - name: night-casino
+    when: hour(time) < 6 && contains(merchant, "casino")
+    violation: night-gambling
+  - name: big-spender
+    order: 5
+    when: sum(10m) > 500
+    violation: amount-velocity
+`)
+
+			// when
+			rules, err := LoadDeclarativeRules(path)
+
+			// then
+			assert.NoError(t, err)
+			assert.Len(t, rules, 2)
+			assert.Equal(t, "night-casino", rules[0].Name)
+			assert.Equal(t, DeclarativeRuleOrder, rules[0].Order)
+			assert.Equal(t, "night-gambling", rules[0].Violation)
+			assert.Equal(t, 5, rules[1].Order)
+		},
+		"Should reject unknown fields": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+rules:
+  - name: big-spender
+    when: amount > 500
+    violation: big-spender
+    severity: high
+`)
+
+			// when
+			_, err := LoadDeclarativeRules(path)
+
+			// then
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "field severity not found")
+		},
+		"Should reject rules without name": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+rules:
+  - when: amount > 500
+    violation: big-spender
+`)
+
+			// when
+			_, err := LoadDeclarativeRules(path)
+
+			// then
+			assert.EqualError(t, err, "rule #1: name is required")
+		},
+		"Should reject invalid violation codes": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+rules:
+  - name: big-spender
+    when: amount > 500
+    violation: Big Spender
+`)
+
+			// when
+			_, err := LoadDeclarativeRules(path)
+
+			// then
+			assert.EqualError(t, err, `rule big-spender: violation must be a kebab-case code, got "Big Spender"`)
+		},
+		"Should not load missing file": func(t *testing.T) {
+			// when
+			_, err := LoadDeclarativeRules("/does/not/exist.yaml")
+
+			// then
+			assert.Error(t, err)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestDeclarativeRule(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should raise custom violation when condition matches": func(t *testing.T) {
+			// given
+			when, _ := ParseExpression(`count(10m) >= 1 && merchant == "Beta"`)
+			rule := DeclarativeRule{Name: "rule", Violation: "custom-violation", When: when}
+			history := NewHistory(Transaction{Merchant: "Alpha", Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)})
+
+			// when
+			errs := rule.Evaluate(Account{}, Transaction{
+				Merchant: "Beta",
+				Time:     time.Date(2020, 7, 12, 10, 5, 0, 0, time.UTC),
+			}, history)
+
+			// then
+			assert.Equal(t, []error{errors.New("custom-violation")}, errs)
+		},
+		"Should not raise violation when condition does not match": func(t *testing.T) {
+			// given
+			when, _ := ParseExpression(`amount > 100`)
+			rule := DeclarativeRule{Name: "rule", Violation: "custom-violation", When: when}
+
+			// when
+			errs := rule.Evaluate(Account{}, Transaction{Amount: 100}, History{})
+
+			// then
+			assert.Empty(t, errs)
+		},
+		"Should register declarative rules after built-in rules": func(t *testing.T) {
+			// given
+			when, _ := ParseExpression(`amount > 100`)
+			registry := NewDefaultRuleRegistry(DefaultConfig())
+
+			// when
+			err := RegisterDeclarativeRules(registry, []DeclarativeRule{
+				{Name: "big-spender", Order: DeclarativeRuleOrder, Violation: "big-spender", When: when},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, "big-spender", registry.Names()[len(registry.Names())-1])
+		},
+		"Should not register declarative rule named after a built-in rule": func(t *testing.T) {
+			// given
+			when, _ := ParseExpression(`amount > 100`)
+			registry := NewDefaultRuleRegistry(DefaultConfig())
+
+			// when
+			err := RegisterDeclarativeRules(registry, []DeclarativeRule{
+				{Name: InsufficientLimit, Order: DeclarativeRuleOrder, Violation: "big-spender", When: when},
+			})
+
+			// then
+			assert.Error(t, err)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func writeRulesFile(t *testing.T, content string) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+	_ = os.WriteFile(path, []byte(content), 0644)
+	return path
+}
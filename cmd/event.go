@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+)
+
+type EventType string
+
+const (
+	AccountCreated              EventType = "AccountCreated"
+	TransactionAuthorized       EventType = "TransactionAuthorized"
+	TransactionDeclined         EventType = "TransactionDeclined"
+	TransactionRefunded         EventType = "TransactionRefunded"
+	TransactionReversed         EventType = "TransactionReversed"
+	TransactionCaptured         EventType = "TransactionCaptured"
+	HoldExpired                 EventType = "HoldExpired"
+	CardStatusChanged           EventType = "CardStatusChanged"
+	CreditLimitChanged          EventType = "CreditLimitChanged"
+	SpendingLimitsChanged       EventType = "SpendingLimitsChanged"
+	VelocityLimitsChanged       EventType = "VelocityLimitsChanged"
+	MerchantListsChanged        EventType = "MerchantListsChanged"
+	CategoryPolicyChanged       EventType = "CategoryPolicyChanged"
+	SimilarityExemptionsChanged EventType = "SimilarityExemptionsChanged"
+)
+
+// Event is an immutable fact about an account. Offset orders events across every account,
+// while Version is the account version the event produced. Declines do not change the account,
+// so they keep the version of the state they were evaluated against.
+type Event struct {
+	Offset               int                   `json:"offset"`
+	Version              int                   `json:"version"`
+	Type                 EventType             `json:"type"`
+	AccountID            int                   `json:"accountId,omitempty"`
+	Account              *Account              `json:"account,omitempty"`
+	Transaction          *Transaction          `json:"transaction,omitempty"`
+	TransactionID        string                `json:"transactionId,omitempty"`
+	Amount               Money                 `json:"amount,omitempty"`
+	CardStatus           CardStatus            `json:"cardStatus,omitempty"`
+	BlockReason          string                `json:"blockReason,omitempty"`
+	CreditLimit          Money                 `json:"creditLimit,omitempty"`
+	SpendingLimits       *SpendingLimits       `json:"spendingLimits,omitempty"`
+	VelocityLimits       *VelocityLimits       `json:"velocityLimits,omitempty"`
+	Merchants            *MerchantLists        `json:"merchants,omitempty"`
+	Categories           *CategoryPolicy       `json:"categories,omitempty"`
+	SimilarityExemptions []SimilarityExemption `json:"similarityExemptions,omitempty"`
+	Conversion           *Conversion           `json:"conversion,omitempty"`
+	Approval             *Approval             `json:"approval,omitempty"`
+	Violations           []string              `json:"violations,omitempty"`
+}
+
+// apply folds the event into the account state it was recorded against.
+func (e Event) apply(acc Account) Account {
+	switch e.Type {
+	case AccountCreated:
+		acc = *e.Account
+	case TransactionAuthorized:
+		acc.AvailableLimit -= e.Transaction.Amount
+		acc.history = acc.history.with(*e.Transaction)
+		if e.Transaction.ID != "" {
+			acc.refundable = acc.refundable.with(*e.Transaction)
+		}
+	case TransactionRefunded:
+		original, found := acc.refundable.find(e.TransactionID)
+		if !found {
+			break
+		}
+		original.refunded += e.Amount
+		acc.AvailableLimit += e.Amount
+		if original.refunded == original.Amount {
+			acc.refundable = acc.refundable.without(original.ID)
+			acc.history = acc.history.without(original.ID)
+		} else {
+			acc.refundable = acc.refundable.with(original)
+		}
+	case TransactionReversed:
+		original, found := acc.refundable.find(e.TransactionID)
+		if !found {
+			break
+		}
+		acc.AvailableLimit += original.Amount - original.refunded
+		acc.refundable = acc.refundable.without(original.ID)
+		acc.history = acc.history.without(original.ID)
+	case TransactionCaptured:
+		captured, found := acc.refundable.find(e.TransactionID)
+		if !found {
+			break
+		}
+		acc.AvailableLimit += captured.Amount - e.Amount
+		captured.Amount = e.Amount
+		captured.Hold = false
+		acc.refundable = acc.refundable.with(captured)
+		if history := acc.history.without(captured.ID); history.Len() < acc.history.Len() {
+			acc.history = history.with(captured)
+		}
+	case HoldExpired:
+		hold, found := acc.refundable.find(e.TransactionID)
+		if !found {
+			break
+		}
+		acc.AvailableLimit += hold.Amount
+		acc.refundable = acc.refundable.without(hold.ID)
+		acc.history = acc.history.without(hold.ID)
+	case CardStatusChanged:
+		acc.CardStatus = e.CardStatus
+		acc.BlockReason = e.BlockReason
+	case CreditLimitChanged:
+		acc.AvailableLimit = e.CreditLimit - acc.usedLimit()
+		acc.CreditLimit = e.CreditLimit
+	case SpendingLimitsChanged:
+		acc.SpendingLimits = e.SpendingLimits
+	case VelocityLimitsChanged:
+		acc.VelocityLimits = e.VelocityLimits
+	case MerchantListsChanged:
+		acc.Merchants = e.Merchants
+	case CategoryPolicyChanged:
+		acc.Categories = e.Categories
+	case SimilarityExemptionsChanged:
+		acc.SimilarityExemptions = e.SimilarityExemptions
+	}
+	acc.version = e.Version
+	return acc
+}
+
+func (e Event) idempotencyKey() string {
+	if e.Transaction == nil {
+		return ""
+	}
+	return e.Transaction.IdempotencyKey
+}
+
+// decision is the answer given to the request the event recorded, with acc being the state the
+// event left the account in.
+func (e Event) decision(acc Account) Decision {
+	acc.conversion = e.Conversion
+	acc.approval = e.Approval
+	return Decision{Account: acc, Errors: e.errors()}
+}
+
+func (e Event) errors() []error {
+	var errs []error
+	for _, violation := range e.Violations {
+		errs = append(errs, errors.New(violation))
+	}
+	return errs
+}
+
+func violations(errs []error) []string {
+	var codes []string
+	for _, err := range errs {
+		codes = append(codes, err.Error())
+	}
+	return codes
+}
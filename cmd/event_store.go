@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const eventsFileName = "events.log"
+
+// EventStore keeps the events recorded, in order, without changing or removing them.
+// Append fails with ErrVersionConflict when the event was not evaluated against the latest
+// version of its account. Once the event is recorded it is passed to project, when informed,
+// before any later event is appended, so a projection of the events follows their order.
+type EventStore interface {
+	Append(e Event, project func(Event)) (Event, error)
+	Events() []Event
+	// EventsAfter calls read with every event following the given offset, in order, stopping
+	// at the first error read returns.
+	EventsAfter(offset int, read func(Event) error) error
+	// AccountEvents returns the events of a single account, in order.
+	AccountEvents(accountID int) []Event
+}
+
+type memoryEventStore struct {
+	mutex  sync.RWMutex
+	events []Event
+	// byAccount keeps the position in events of the events of each account.
+	byAccount map[int][]int
+	versions  map[int]int
+	offset    int
+	// transient stores forget every event once appended, keeping only the offset and the
+	// versions checked by the next appends.
+	transient bool
+}
+
+func NewMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{byAccount: map[int][]int{}, versions: map[int]int{}}
+}
+
+// NewTransientEventStore checks appends like a memory store without keeping the events,
+// for batches whose events are never queried.
+func NewTransientEventStore() *memoryEventStore {
+	store := NewMemoryEventStore()
+	store.transient = true
+	return store
+}
+
+// Append assigns the next offset to the event, starting from 1.
+func (s *memoryEventStore) Append(e Event, project func(Event)) (Event, error) {
+	return s.appendWith(e, func(Event) error { return nil }, project)
+}
+
+// appendWith calls persist with the event about to be appended while holding the lock,
+// so stores keeping a copy elsewhere see events in the same order. The event is only
+// appended once persist succeeds, and only then projected.
+func (s *memoryEventStore) appendWith(e Event, persist func(Event) error, project func(Event)) (Event, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expected := s.versions[e.AccountID] + 1
+	if e.Type == TransactionDeclined {
+		expected--
+	}
+	if e.Version != expected {
+		return e, ErrVersionConflict
+	}
+
+	e.Offset = s.offset + 1
+	if err := persist(e); err != nil {
+		return e, err
+	}
+	s.offset = e.Offset
+	s.versions[e.AccountID] = e.Version
+	if !s.transient {
+		s.byAccount[e.AccountID] = append(s.byAccount[e.AccountID], len(s.events))
+		s.events = append(s.events, e)
+	}
+	if project != nil {
+		project(e)
+	}
+	return e, nil
+}
+
+func (s *memoryEventStore) Events() []Event {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]Event{}, s.events...)
+}
+
+func (s *memoryEventStore) EventsAfter(offset int, read func(Event) error) error {
+	s.mutex.RLock()
+	events := s.events[min(offset, len(s.events)):]
+	s.mutex.RUnlock()
+
+	for _, e := range events {
+		if err := read(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryEventStore) AccountEvents(accountID int) []Event {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	events := make([]Event, 0, len(s.byAccount[accountID]))
+	for _, i := range s.byAccount[accountID] {
+		events = append(events, s.events[i])
+	}
+	return events
+}
+
+// fileEventStore appends every event to a checksummed log that is never compacted. Only the
+// position of each event in the log is kept in memory, and events are read back from it.
+type fileEventStore struct {
+	memory *memoryEventStore
+	log    *os.File
+	mutex  sync.RWMutex
+	// positions keeps where each event starts in the log, by offset starting from 1.
+	positions []int64
+	// byAccount keeps the offsets of the events of each account.
+	byAccount map[int][]int
+	// size is the length of the log up to its last intact event. A failed append is
+	// truncated back to it before another event is written, since an event following
+	// a torn one would be discarded on recovery.
+	size   int64
+	failed bool
+}
+
+// OpenFileEventStore indexes the events kept in dir, creating the directory when needed.
+func OpenFileEventStore(dir string) (*fileEventStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	store := &fileEventStore{memory: NewTransientEventStore(), byAccount: map[int][]int{}}
+	log, err := openLog(filepath.Join(dir, eventsFileName), func(position int64, payload []byte) error {
+		var e Event
+		err := json.Unmarshal(payload, &e)
+		if err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		if e, err = store.memory.Append(e, nil); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		store.index(e, position)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	store.log = log
+	if store.size, err = log.Seek(0, io.SeekCurrent); err != nil {
+		log.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Append fails with a StorageError when the event cannot be made durable, as dbFile does.
+// Events are only projected once durable.
+func (s *fileEventStore) Append(e Event, project func(Event)) (Event, error) {
+	return s.memory.appendWith(e, func(e Event) error {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if s.failed {
+			if err := s.discardTail(); err != nil {
+				return &StorageError{Err: err}
+			}
+		}
+		if err := appendFrame(s.log, payload); err != nil {
+			s.failed = true
+			return &StorageError{Err: err}
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.index(e, s.size)
+		s.size += int64(frameHeaderSize + len(payload))
+		return nil
+	}, project)
+}
+
+func (s *fileEventStore) index(e Event, position int64) {
+	s.positions = append(s.positions, position)
+	s.byAccount[e.AccountID] = append(s.byAccount[e.AccountID], e.Offset)
+}
+
+func (s *fileEventStore) discardTail() error {
+	if err := s.log.Truncate(s.size); err != nil {
+		return err
+	}
+	if _, err := s.log.Seek(s.size, io.SeekStart); err != nil {
+		return err
+	}
+	s.failed = false
+	return nil
+}
+
+// Events reads the whole log, so it is only meant for small ones.
+func (s *fileEventStore) Events() []Event {
+	var events []Event
+	_ = s.EventsAfter(0, func(e Event) error {
+		events = append(events, e)
+		return nil
+	})
+	return events
+}
+
+func (s *fileEventStore) EventsAfter(offset int, read func(Event) error) error {
+	s.mutex.RLock()
+	end := s.size
+	from := end
+	if offset < len(s.positions) {
+		from = s.positions[offset]
+	}
+	s.mutex.RUnlock()
+
+	frames := newFrameReader(s.log, from, end)
+	for {
+		payload, err := frames.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var e Event
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return err
+		}
+		if err := read(e); err != nil {
+			return err
+		}
+	}
+}
+
+// AccountEvents reads each event of the account from where it starts in the log. Events that
+// cannot be read back are left out.
+func (s *fileEventStore) AccountEvents(accountID int) []Event {
+	s.mutex.RLock()
+	positions := make([]int64, 0, len(s.byAccount[accountID]))
+	for _, offset := range s.byAccount[accountID] {
+		positions = append(positions, s.positions[offset-1])
+	}
+	end := s.size
+	s.mutex.RUnlock()
+
+	events := make([]Event, 0, len(positions))
+	for _, position := range positions {
+		payload, err := newFrameReader(s.log, position, end).next()
+		if err != nil {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(payload, &e); err == nil {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (s *fileEventStore) Close() error {
+	return s.log.Close()
+}
+
+// checkpointed is implemented by databases that keep how far they reflect the events.
+type checkpointed interface {
+	Checkpoint() int
+}
+
+// Rebuild folds into db every event it does not reflect yet, along with the idempotency
+// decisions they carry. An empty db gets the whole projection rebuilt, while a persisted one
+// catches up with events recorded right before a crash, replaying only those after its
+// checkpoint. Only the decisions of the latest DecisionRetention keys of each account are
+// stored again. It stops at the first change db cannot store.
+func Rebuild(events EventStore, db DB) error {
+	after := 0
+	if c, ok := db.(checkpointed); ok {
+		after = c.Checkpoint()
+	}
+
+	keyed := map[int]int{}
+	err := events.EventsAfter(after, func(e Event) error {
+		if e.idempotencyKey() != "" {
+			keyed[e.AccountID]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return events.EventsAfter(after, func(e Event) error {
+		acc, err := db.FindAccount(e.AccountID)
+		switch {
+		case e.Type == AccountCreated && err != nil:
+			acc, err = db.CreateAccount(e.apply(Account{}))
+		case err != nil:
+			return nil
+		case e.Version > acc.version:
+			acc, err = db.UpdateAccount(e.apply(acc), e)
+		}
+		if err != nil {
+			return err
+		}
+
+		key := e.idempotencyKey()
+		if key == "" {
+			return nil
+		}
+		keyed[e.AccountID]--
+		if keyed[e.AccountID] >= DecisionRetention || e.Version != acc.version {
+			return nil
+		}
+		if _, err := db.FindDecision(acc.ID, key); err != nil {
+			return db.SaveDecision(acc.ID, key, e.decision(acc))
+		}
+		return nil
+	})
+}
+
+// StateAt folds the events of an account up to the given offset, returning the account
+// as it was right after that event.
+func StateAt(events EventStore, accountID int, offset int) (Account, error) {
+	acc, found := Account{}, false
+	for _, e := range events.AccountEvents(accountID) {
+		if e.Offset > offset {
+			break
+		}
+		found = found || e.Type == AccountCreated
+		acc = e.apply(acc)
+	}
+	if !found {
+		return Account{}, errors.New("account not found")
+	}
+	return acc, nil
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryEventStore(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should assign increasing offsets to appended events": func(t *testing.T) {
+			// given
+			store := NewMemoryEventStore()
+
+			// when
+			first, _ := store.Append(accountCreated(1, 100), nil)
+			second, _ := store.Append(accountCreated(2, 200), nil)
+
+			// then
+			assert.Equal(t, 1, first.Offset)
+			assert.Equal(t, 2, second.Offset)
+			assert.Equal(t, []Event{first, second}, store.Events())
+		},
+		"Should not append event evaluated against an outdated version": func(t *testing.T) {
+			// given
+			store := NewMemoryEventStore()
+			store.Append(accountCreated(1, 100), nil)
+			store.Append(Event{Type: CreditLimitChanged, AccountID: 1, Version: 2, CreditLimit: 200}, nil)
+
+			// when
+			_, changed := store.Append(Event{Type: CreditLimitChanged, AccountID: 1, Version: 2, CreditLimit: 300}, nil)
+			_, declined := store.Append(Event{Type: TransactionDeclined, AccountID: 1, Version: 1, Transaction: &Transaction{}}, nil)
+
+			// then
+			assert.Equal(t, ErrVersionConflict, changed)
+			assert.Equal(t, ErrVersionConflict, declined)
+			assert.Len(t, store.Events(), 2)
+		},
+		"Should not create the same account twice": func(t *testing.T) {
+			// given
+			store := NewMemoryEventStore()
+			store.Append(accountCreated(1, 100), nil)
+
+			// when
+			_, err := store.Append(accountCreated(1, 200), nil)
+
+			// then
+			assert.Equal(t, ErrVersionConflict, err)
+		},
+		"Should find the events of a single account": func(t *testing.T) {
+			// given
+			store := NewMemoryEventStore()
+			created, _ := store.Append(accountCreated(1, 100), nil)
+			store.Append(accountCreated(2, 200), nil)
+			changed, _ := store.Append(Event{Type: CreditLimitChanged, AccountID: 1, Version: 2, CreditLimit: 300}, nil)
+
+			// when
+			res := store.AccountEvents(1)
+
+			// then
+			assert.Equal(t, []Event{created, changed}, res)
+			assert.Empty(t, store.AccountEvents(3))
+		},
+		"Should check versions without keeping events when transient": func(t *testing.T) {
+			// given
+			store := NewTransientEventStore()
+			store.Append(accountCreated(1, 100), nil)
+
+			// when
+			changed, err := store.Append(Event{Type: CreditLimitChanged, AccountID: 1, Version: 2, CreditLimit: 300}, nil)
+			_, conflict := store.Append(Event{Type: CreditLimitChanged, AccountID: 1, Version: 2, CreditLimit: 400}, nil)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, 2, changed.Offset)
+			assert.Equal(t, ErrVersionConflict, conflict)
+			assert.Empty(t, store.Events())
+			assert.Empty(t, store.AccountEvents(1))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestFileEventStore(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should load events after reopening": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			store, _ := OpenFileEventStore(dir)
+			created, _ := store.Append(accountCreated(1, 100), nil)
+			declined, _ := store.Append(Event{
+				Type:        TransactionDeclined,
+				AccountID:   1,
+				Version:     1,
+				Transaction: &Transaction{AccountID: 1, Merchant: "Acme Corporation", Amount: 200, Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)},
+				Violations:  []string{InsufficientLimit},
+			}, nil)
+			store.Close()
+
+			// when
+			res, err := OpenFileEventStore(dir)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, []Event{created, declined}, res.Events())
+		},
+		"Should discard a partially written event and keep the offsets": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			store, _ := OpenFileEventStore(dir)
+			store.Append(accountCreated(1, 100), nil)
+			store.Append(accountCreated(2, 200), nil)
+			store.Close()
+			truncateFile(t, filepath.Join(dir, eventsFileName), 4)
+
+			// when
+			res, err := OpenFileEventStore(dir)
+			appended, _ := res.Append(accountCreated(3, 300), nil)
+
+			// then
+			assert.NoError(t, err)
+			assert.Len(t, res.Events(), 2)
+			assert.Equal(t, 2, appended.Offset)
+		},
+		"Should fail events that cannot be written to the log": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			store, _ := OpenFileEventStore(dir)
+			store.log.Close()
+
+			// when
+			_, err := store.Append(accountCreated(1, 100), nil)
+
+			// then
+			var storageErr *StorageError
+			assert.ErrorAs(t, err, &storageErr)
+			assert.EqualError(t, err, StorageUnavailable)
+			assert.Empty(t, store.Events())
+		},
+		"Should discard a failed append before writing the next event": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			store, _ := OpenFileEventStore(dir)
+			created, _ := store.Append(accountCreated(1, 100), nil)
+			log := store.log
+			store.log, _ = os.Open(filepath.Join(dir, eventsFileName))
+			_, failed := store.Append(accountCreated(2, 200), nil)
+			store.log.Close()
+			store.log = log
+
+			// when
+			appended, err := store.Append(accountCreated(2, 200), nil)
+			store.Close()
+
+			// then
+			assert.Error(t, failed)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, appended.Offset)
+			res, _ := OpenFileEventStore(dir)
+			assert.Equal(t, []Event{created, appended}, res.Events())
+		},
+		"Should read the events of an account and those after an offset back from the log": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			store, _ := OpenFileEventStore(dir)
+			store.Append(accountCreated(1, 100), nil)
+			store.Append(accountCreated(2, 200), nil)
+			store.Close()
+			res, _ := OpenFileEventStore(dir)
+			changed, _ := res.Append(Event{Type: CreditLimitChanged, AccountID: 1, Version: 2, CreditLimit: 150}, nil)
+
+			// when
+			var after []Event
+			err := res.EventsAfter(1, func(e Event) error {
+				after = append(after, e)
+				return nil
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, []int{2, 3}, []int{after[0].Offset, after[1].Offset})
+			account := res.AccountEvents(1)
+			assert.Len(t, account, 2)
+			assert.Equal(t, AccountCreated, account[0].Type)
+			assert.Equal(t, changed, account[1])
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	tr := Transaction{
+		ID:             "t1",
+		AccountID:      1,
+		Merchant:       "Acme Corporation",
+		Amount:         20,
+		Time:           time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC),
+		IdempotencyKey: "order-1",
+	}
+	record := func() (EventStore, *dbMemory) {
+		events := NewMemoryEventStore()
+		db := NewMemoryDB()
+		m := NewAccountManager(db).WithEvents(events)
+		acc, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+		acc, _ = m.Authorize(acc, tr)
+		acc, _ = m.Refund(acc, Refund{TransactionID: "t1", Amount: 5})
+		m.Update(acc, CardOperation{Action: BlockCard, Reason: "lost card"})
+		return events, db
+	}
+
+	tests := map[string]func(*testing.T){
+		"Should rebuild the projection from every event": func(t *testing.T) {
+			// given
+			events, original := record()
+			db := NewMemoryDB()
+
+			// when
+			Rebuild(events, db)
+
+			// then
+			expected, _ := original.FindAccount(1)
+			rebuilt, _ := db.FindAccount(1)
+			assert.Equal(t, expected, rebuilt)
+			assert.Equal(t, Money(85), rebuilt.AvailableLimit)
+			assert.Equal(t, CardBlocked, rebuilt.CardStatus)
+			decision, err := db.FindDecision(1, "order-1")
+			assert.NoError(t, err)
+			assert.Equal(t, Money(80), decision.Account.AvailableLimit)
+		},
+		"Should only apply events missing from the projection": func(t *testing.T) {
+			// given
+			events, db := record()
+			acc, _ := db.FindAccount(1)
+			stale, _ := StateAt(events, 1, 2)
+			db.store(stale)
+
+			// when
+			Rebuild(events, db)
+
+			// then
+			rebuilt, _ := db.FindAccount(1)
+			assert.Equal(t, acc, rebuilt)
+		},
+		"Should only replay the events after the checkpoint of db": func(t *testing.T) {
+			// given
+			events, _ := record()
+			replayed, _ := OpenFileDB(t.TempDir())
+			skipped, _ := OpenFileDB(t.TempDir())
+			skipped.checkpoint = 1
+
+			// when
+			Rebuild(events, replayed)
+			Rebuild(events, skipped)
+
+			// then
+			_, err := replayed.FindAccount(1)
+			assert.NoError(t, err)
+			_, err = skipped.FindAccount(1)
+			assert.Error(t, err)
+		},
+		"Should not store again decisions beyond the retention": func(t *testing.T) {
+			// given
+			events := NewMemoryEventStore()
+			m := NewAccountManager(NewMemoryDB()).WithEvents(events)
+			acc, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: Units(DecisionRetention + 1)})
+			for i := 0; i <= DecisionRetention; i++ {
+				acc, _ = m.Authorize(acc, Transaction{
+					AccountID:      1,
+					Merchant:       fmt.Sprintf("Merchant %d", i),
+					Amount:         1,
+					Time:           tr.Time.Add(time.Duration(i) * time.Hour),
+					IdempotencyKey: fmt.Sprintf("order-%d", i),
+				})
+			}
+			db := NewMemoryDB()
+
+			// when
+			Rebuild(events, db)
+
+			// then
+			_, err := db.FindDecision(1, "order-0")
+			assert.Error(t, err)
+			_, err = db.FindDecision(1, "order-1")
+			assert.NoError(t, err)
+			assert.Len(t, db.decided[1], DecisionRetention)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestStateAt(t *testing.T) {
+	events := NewMemoryEventStore()
+	m := NewAccountManager(NewMemoryDB()).WithEvents(events)
+	acc, _ := m.Initialize(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+	m.Initialize(Account{ID: 2, CardStatus: CardActive, AvailableLimit: 200})
+	acc, _ = m.Authorize(acc, Transaction{AccountID: 1, Merchant: "Acme Corporation", Amount: 20, Time: time.Now()})
+	m.Update(acc, AccountUpdate{AccountID: 1, CreditLimit: 300})
+
+	tests := map[string]func(*testing.T){
+		"Should fold events of the account up to the offset": func(t *testing.T) {
+			// when
+			res, err := StateAt(events, 1, 3)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, Money(80), res.AvailableLimit)
+			assert.Equal(t, Money(100), res.CreditLimit)
+		},
+		"Should fold every event of the account after the last offset": func(t *testing.T) {
+			// when
+			res, err := StateAt(events, 1, 100)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, Money(280), res.AvailableLimit)
+			assert.Equal(t, Money(300), res.CreditLimit)
+		},
+		"Should not find account created after the offset": func(t *testing.T) {
+			// when
+			_, err := StateAt(events, 2, 1)
+
+			// then
+			assert.Equal(t, errors.New("account not found"), err)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func accountCreated(id int, limit Money) Event {
+	return Event{
+		Type:      AccountCreated,
+		AccountID: id,
+		Version:   1,
+		Account:   &Account{ID: id, CardStatus: CardActive, CreditLimit: limit, AvailableLimit: limit},
+	}
+}
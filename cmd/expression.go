@@ -0,0 +1,481 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expression is a boolean condition over a transaction and the account it targets, written in a small
+// language such as `amount > 500 && count(10m) >= 3`.
+type Expression struct {
+	source string
+	root   node
+	window time.Duration
+}
+
+type expressionEnv struct {
+	acc     Account
+	tr      Transaction
+	history History
+}
+
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+	if root.kind() != kindBool {
+		return nil, fmt.Errorf("expression must be a condition, got %s", root.kind())
+	}
+
+	return &Expression{source: source, root: root, window: p.window}, nil
+}
+
+func (e *Expression) Matches(acc Account, tr Transaction, history History) bool {
+	return e.root.eval(expressionEnv{acc: acc, tr: tr, history: history}).(bool)
+}
+
+// Window is the largest duration looked back by the expression.
+func (e *Expression) Window() time.Duration {
+	return e.window
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+type valueKind string
+
+const (
+	kindNumber   valueKind = "number"
+	kindString   valueKind = "string"
+	kindBool     valueKind = "bool"
+	kindDuration valueKind = "duration"
+	kindTime     valueKind = "time"
+)
+
+type node interface {
+	kind() valueKind
+	eval(env expressionEnv) interface{}
+}
+
+type literalNode struct {
+	valueKind valueKind
+	value     interface{}
+}
+
+func (n literalNode) kind() valueKind                { return n.valueKind }
+func (n literalNode) eval(expressionEnv) interface{} { return n.value }
+
+type fieldNode struct {
+	valueKind valueKind
+	value     func(env expressionEnv) interface{}
+}
+
+func (n fieldNode) kind() valueKind                    { return n.valueKind }
+func (n fieldNode) eval(env expressionEnv) interface{} { return n.value(env) }
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) kind() valueKind                    { return kindBool }
+func (n notNode) eval(env expressionEnv) interface{} { return !n.operand.eval(env).(bool) }
+
+type logicalNode struct {
+	operator    string
+	left, right node
+}
+
+func (n logicalNode) kind() valueKind { return kindBool }
+
+func (n logicalNode) eval(env expressionEnv) interface{} {
+	left := n.left.eval(env).(bool)
+	if n.operator == "&&" {
+		return left && n.right.eval(env).(bool)
+	}
+	return left || n.right.eval(env).(bool)
+}
+
+type comparisonNode struct {
+	operator    string
+	left, right node
+}
+
+func (n comparisonNode) kind() valueKind { return kindBool }
+
+func (n comparisonNode) eval(env expressionEnv) interface{} {
+	left, right := n.left.eval(env), n.right.eval(env)
+	switch n.operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	}
+
+	l, r := left.(float64), right.(float64)
+	switch n.operator {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
+
+type callNode struct {
+	valueKind valueKind
+	args      []node
+	call      func(env expressionEnv, args []interface{}) interface{}
+}
+
+func (n callNode) kind() valueKind { return n.valueKind }
+
+func (n callNode) eval(env expressionEnv) interface{} {
+	var args []interface{}
+	for _, arg := range n.args {
+		args = append(args, arg.eval(env))
+	}
+	return n.call(env, args)
+}
+
+var expressionFields = map[string]fieldNode{
+	"merchant": {kindString, func(env expressionEnv) interface{} { return env.tr.Merchant }},
+	"amount":   {kindNumber, func(env expressionEnv) interface{} { return env.tr.Amount.Float() }},
+	"time":     {kindTime, func(env expressionEnv) interface{} { return env.tr.Time }},
+	"availableLimit": {kindNumber, func(env expressionEnv) interface{} {
+		return env.acc.AvailableLimit.Float()
+	}},
+	"activeCard": {kindBool, func(env expressionEnv) interface{} { return env.acc.ActiveCard() }},
+	"cardStatus": {kindString, func(env expressionEnv) interface{} { return string(env.acc.CardStatus) }},
+}
+
+type expressionFunction struct {
+	params []valueKind
+	result valueKind
+	call   func(env expressionEnv, args []interface{}) interface{}
+}
+
+var expressionFunctions = map[string]expressionFunction{
+	"count": {[]valueKind{kindDuration}, kindNumber, func(env expressionEnv, args []interface{}) interface{} {
+		return float64(len(transactionsWithin(env, args[0].(time.Duration))))
+	}},
+	"sum": {[]valueKind{kindDuration}, kindNumber, func(env expressionEnv, args []interface{}) interface{} {
+		sum := 0.0
+		for _, t := range transactionsWithin(env, args[0].(time.Duration)) {
+			sum += t.Amount.Float()
+		}
+		return sum
+	}},
+	"hour": {[]valueKind{kindTime}, kindNumber, func(_ expressionEnv, args []interface{}) interface{} {
+		return float64(args[0].(time.Time).UTC().Hour())
+	}},
+	"contains": {[]valueKind{kindString, kindString}, kindBool, func(_ expressionEnv, args []interface{}) interface{} {
+		return strings.Contains(strings.ToLower(args[0].(string)), strings.ToLower(args[1].(string)))
+	}},
+}
+
+func transactionsWithin(env expressionEnv, window time.Duration) []Transaction {
+	return env.history.Between(env.tr.Time.Add(-window), env.tr.Time)
+}
+
+type parser struct {
+	tokens   []token
+	position int
+	window   time.Duration
+}
+
+func (p *parser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: tokenEnd, text: "end of expression", pos: -1}
+	}
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.position++
+	return t
+}
+
+func (p *parser) expect(text string) error {
+	t := p.next()
+	if t.text != text {
+		return fmt.Errorf("expected %q but found %q at position %d", text, t.text, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical("&&", p.parseUnary)
+}
+
+func (p *parser) parseLogical(operator string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == operator {
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindBool || right.kind() != kindBool {
+			return nil, fmt.Errorf("operator %s requires conditions on both sides", operator)
+		}
+		left = logicalNode{operator: operator, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().text == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != kindBool {
+			return nil, fmt.Errorf("operator ! requires a condition")
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := p.peek()
+	if operator.kind != tokenComparison {
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("cannot compare %s with %s at position %d", left.kind(), right.kind(), operator.pos)
+	}
+	equality := operator.text == "==" || operator.text == "!="
+	if !equality && left.kind() != kindNumber {
+		return nil, fmt.Errorf("operator %s requires numbers at position %d", operator.text, operator.pos)
+	}
+	if equality && left.kind() != kindNumber && left.kind() != kindString && left.kind() != kindBool {
+		return nil, fmt.Errorf("cannot compare %s values at position %d", left.kind(), operator.pos)
+	}
+	return comparisonNode{operator: operator.text, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return literalNode{kindNumber, value}, nil
+	case tokenDuration:
+		value, err := parseWindow(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q at position %d", t.text, t.pos)
+		}
+		if value > p.window {
+			p.window = value
+		}
+		return literalNode{kindDuration, value}, nil
+	case tokenString:
+		value, err := strconv.Unquote(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s at position %d", t.text, t.pos)
+		}
+		return literalNode{kindString, value}, nil
+	case tokenIdentifier:
+		if t.text == "true" || t.text == "false" {
+			return literalNode{kindBool, t.text == "true"}, nil
+		}
+		if p.peek().text == "(" {
+			return p.parseCall(t)
+		}
+		field, ok := expressionFields[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q at position %d", t.text, t.pos)
+		}
+		return field, nil
+	case tokenParenthesis:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	function, ok := expressionFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	_ = p.expect("(")
+
+	var args []node
+	for p.peek().text != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	if len(args) != len(function.params) {
+		return nil, fmt.Errorf("function %s expects %d arguments, got %d", name.text, len(function.params), len(args))
+	}
+	for i, arg := range args {
+		if arg.kind() != function.params[i] {
+			return nil, fmt.Errorf("function %s expects %s as argument %d, got %s", name.text, function.params[i], i+1, arg.kind())
+		}
+	}
+	return callNode{valueKind: function.result, args: args, call: function.call}, nil
+}
+
+// parseWindow accepts Go durations along with days, e.g. `90s`, `10m`, `1h30m` or `7d`.
+func parseWindow(text string) (time.Duration, error) {
+	if strings.HasSuffix(text, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(text)
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenDuration
+	tokenString
+	tokenIdentifier
+	tokenComparison
+	tokenLogical
+	tokenParenthesis
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			kind := tokenNumber
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				kind = tokenDuration
+				i++
+			}
+			tokens = append(tokens, token{kind, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdentifier, string(runes[start:i]), start})
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{tokenString, string(runes[start:i]), start})
+		case r == '(' || r == ')':
+			i++
+			tokens = append(tokens, token{tokenParenthesis, string(r), start})
+		case r == ',':
+			i++
+			tokens = append(tokens, token{tokenComma, ",", start})
+		default:
+			operator := matchOperator(string(runes[i:]))
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, start)
+			}
+			i += len(operator)
+			kind := tokenComparison
+			if operator == "&&" || operator == "||" || operator == "!" {
+				kind = tokenLogical
+			}
+			tokens = append(tokens, token{kind, operator, start})
+		}
+	}
+	return tokens, nil
+}
+
+func matchOperator(rest string) string {
+	for _, operator := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"} {
+		if strings.HasPrefix(rest, operator) {
+			return operator
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should parse valid expressions": func(t *testing.T) {
+			valid := []string{
+				`amount > 500`,
+				`merchant == "Acme Corporation" && amount >= 100`,
+				`!activeCard || availableLimit < 100`,
+				`count(10m) >= 3 || sum(1h30m) > 1000`,
+				`hour(time) < 6 && contains(merchant, "casino")`,
+				`(amount > 10 && amount < 20) || sum(7d) == 0`,
+			}
+			for _, source := range valid {
+				_, err := ParseExpression(source)
+				assert.NoError(t, err, source)
+			}
+		},
+		"Should reject invalid expressions": func(t *testing.T) {
+			invalid := map[string]string{
+				`amount`:                     "expression must be a condition, got number",
+				`amount > "100"`:             "cannot compare number with string at position 7",
+				`merchant > "Acme"`:          "operator > requires numbers at position 9",
+				`unknown == 1`:               `unknown field "unknown" at position 0`,
+				`count(10) > 1`:              "function count expects duration as argument 1, got number",
+				`sum() > 1`:                  "function sum expects 1 arguments, got 0",
+				`foo(1m) > 1`:                `unknown function "foo" at position 0`,
+				`amount > 1 &&`:              `unexpected "end of expression" at position -1`,
+				`(amount > 1`:                `expected ")" but found "end of expression" at position -1`,
+				`merchant == "Acme`:          "unterminated string at position 12",
+				`amount > 1 amount`:          `unexpected "amount" at position 11`,
+				`amount # 1`:                 `unexpected character '#' at position 7`,
+				`activeCard && amount`:       "operator && requires conditions on both sides",
+				`time == time`:               "cannot compare time values at position 5",
+				`count(10x) > 1`:             `invalid duration "10x" at position 6`,
+				`!amount`:                    "operator ! requires a condition",
+				`contains(merchant) == true`: "function contains expects 2 arguments, got 1",
+				`availableLimit - 1 < 0`:     `unexpected character '-' at position 15`,
+			}
+			for source, message := range invalid {
+				_, err := ParseExpression(source)
+				assert.EqualError(t, err, message, source)
+			}
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestMatchesExpression(t *testing.T) {
+	acc := Account{
+		CardStatus:     CardActive,
+		AvailableLimit: Units(1000),
+	}
+	history := NewHistory(
+		Transaction{Merchant: "Alpha", Amount: Units(100), Time: time.Date(2020, 7, 12, 2, 0, 0, 0, time.UTC)},
+		Transaction{Merchant: "Beta", Amount: Units(200), Time: time.Date(2020, 7, 12, 2, 50, 0, 0, time.UTC)},
+		Transaction{Merchant: "Gamma", Amount: Units(300), Time: time.Date(2020, 7, 12, 2, 55, 0, 0, time.UTC)},
+	)
+	tr := Transaction{
+		Merchant: "Lucky Casino",
+		Amount:   Units(50),
+		Time:     time.Date(2020, 7, 12, 3, 0, 0, 0, time.UTC),
+	}
+
+	tests := map[string]func(*testing.T){
+		"Should evaluate expressions against transaction, account and history": func(t *testing.T) {
+			cases := map[string]bool{
+				`amount == 50`:                          true,
+				`amount > 50`:                           false,
+				`merchant != "Alpha"`:                   true,
+				`contains(merchant, "CASINO")`:          true,
+				`availableLimit >= 1000 && activeCard`:  true,
+				`!activeCard`:                           false,
+				`hour(time) < 6`:                        true,
+				`count(10m) == 2`:                       true,
+				`count(1h) == 3`:                        true,
+				`sum(10m) > 500`:                        false,
+				`sum(10m) == 500 || amount > 1000`:      true,
+				`(count(1h) > 2 && sum(1h) >= 600)`:     true,
+				`count(1m) == 0 && activeCard == true`:  true,
+				`amount < 10 || (amount > 40 && false)`: false,
+			}
+			for source, expected := range cases {
+				expression, err := ParseExpression(source)
+				assert.NoError(t, err, source)
+				assert.Equal(t, expected, expression.Matches(acc, tr, history), source)
+			}
+		},
+		"Should look back as far as the largest duration": func(t *testing.T) {
+			cases := map[string]time.Duration{
+				`amount > 50`:                   0,
+				`count(10m) > 2`:                10 * time.Minute,
+				`count(10m) > 2 || sum(1h) > 0`: time.Hour,
+			}
+			for source, expected := range cases {
+				expression, _ := ParseExpression(source)
+				assert.Equal(t, expected, expression.Window(), source)
+			}
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
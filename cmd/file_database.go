@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	DefaultSnapshotInterval = 1000
+
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.db"
+	frameHeaderSize  = 8
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var errCorruptedFrame = errors.New("corrupted frame")
+
+// dbFile keeps the same state as dbMemory but appends every change to a write-ahead log
+// before applying it, so the state can be rebuilt after a restart or a crash. Accounts are
+// logged in full when created and then only through the events changing them.
+// Each record is framed by its length and checksum, allowing a torn write left by
+// a crash to be detected and discarded on recovery. The log is compacted into a
+// snapshot every snapshotInterval records.
+type dbFile struct {
+	mutex            sync.Mutex
+	memory           *dbMemory
+	dir              string
+	wal              *os.File
+	records          int
+	snapshotInterval int
+	// checkpoint is the offset up to which every event is known to be stored. It is only
+	// raised once a later change is stored, as its projection is complete by then.
+	checkpoint int
+	// failed is set when a record could not be appended, leaving the log with a tail that
+	// no further record may follow.
+	failed bool
+}
+
+type walRecord struct {
+	Account  *accountRecord  `json:"account,omitempty"`
+	Change   *Event          `json:"change,omitempty"`
+	Decision *decisionRecord `json:"decision,omitempty"`
+}
+
+type snapshot struct {
+	Accounts   []accountRecord  `json:"accounts"`
+	Decisions  []decisionRecord `json:"decisions"`
+	Checkpoint int              `json:"checkpoint,omitempty"`
+}
+
+type accountRecord struct {
+	Account      Account             `json:"account"`
+	History      []Transaction       `json:"history,omitempty"`
+	Transactions []transactionRecord `json:"transactions,omitempty"`
+	Version      int                 `json:"version,omitempty"`
+}
+
+type transactionRecord struct {
+	Transaction
+	Refunded Money `json:"refunded,omitempty"`
+}
+
+type decisionRecord struct {
+	AccountID  int         `json:"accountId"`
+	Key        string      `json:"key"`
+	Account    Account     `json:"account"`
+	Conversion *Conversion `json:"conversion,omitempty"`
+	Approval   *Approval   `json:"approval,omitempty"`
+	Violations []string    `json:"violations,omitempty"`
+}
+
+// OpenFileDB recovers the state kept in dir, creating the directory when needed.
+func OpenFileDB(dir string) (*dbFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	db := &dbFile{
+		memory:           NewMemoryDB(),
+		dir:              dir,
+		snapshotInterval: DefaultSnapshotInterval,
+	}
+	if err := db.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := db.replay(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *dbFile) CreateAccount(acc Account) (Account, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if existing, err := db.memory.FindAccount(acc.ID); err == nil {
+		return existing, errors.New("account already exists")
+	}
+	if err := db.write(walRecord{Account: newAccountRecord(acc)}); err != nil {
+		return acc, err
+	}
+	return acc, nil
+}
+
+func (db *dbFile) UpdateAccount(acc Account, change Event) (Account, error) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	current, err := db.memory.FindAccount(acc.ID)
+	if err == nil && current.version != change.Version-1 {
+		return current, ErrVersionConflict
+	}
+	if err != nil {
+		return acc, err
+	}
+	change.AccountID = acc.ID
+	if err := db.write(walRecord{Change: &change}); err != nil {
+		return acc, err
+	}
+	return acc, nil
+}
+
+func (db *dbFile) FindAccount(id int) (Account, error) {
+	return db.memory.FindAccount(id)
+}
+
+func (db *dbFile) SaveDecision(accountID int, key string, decision Decision) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.write(walRecord{Decision: newDecisionRecord(decisionKey{accountID, key}, decision)})
+}
+
+func (db *dbFile) FindDecision(accountID int, key string) (Decision, error) {
+	return db.memory.FindDecision(accountID, key)
+}
+
+// Checkpoint returns the offset of the latest event stored along with every event before it,
+// so Rebuild only replays the events that follow.
+func (db *dbFile) Checkpoint() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.checkpoint
+}
+
+func (db *dbFile) Close() error {
+	return db.wal.Close()
+}
+
+// write fails with a StorageError when a change cannot be made durable. The change is applied
+// in memory all the same: dbFile only stores what events already made durable, and Rebuild
+// stores it again from them on restart, while refusing it would leave the state behind the
+// events. After a failed append the log is replaced by a snapshot, which then carries the
+// change, before anything else is written, as a record following a torn one would be
+// discarded on recovery. A failed snapshot is otherwise retried on the next write, as the
+// log alone is enough to recover.
+func (db *dbFile) write(record walRecord) error {
+	var err error
+	if db.failed {
+		err = db.compact()
+		db.failed = err != nil
+	}
+	if err == nil {
+		err = db.append(record)
+		db.failed = err != nil
+	}
+	db.apply(record)
+	if err != nil {
+		return &StorageError{Err: err}
+	}
+
+	db.records++
+	if db.records >= db.snapshotInterval {
+		_ = db.compact()
+	}
+	return nil
+}
+
+func (db *dbFile) append(record walRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return appendFrame(db.wal, payload)
+}
+
+// Snapshot writes the whole state to a new snapshot file and empties the log.
+func (db *dbFile) Snapshot() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return db.compact()
+}
+
+// compact is called while holding the mutex. The snapshot is renamed into place only once
+// it is fully written, and changes are only applied to the version they were recorded against,
+// so replaying a log that outlived a crash right after the rename produces the same state again.
+func (db *dbFile) compact() error {
+	state := snapshot{Checkpoint: db.checkpoint}
+	for _, acc := range db.memory.account {
+		state.Accounts = append(state.Accounts, *newAccountRecord(acc))
+	}
+	for accountID, keys := range db.memory.decided {
+		for _, key := range keys {
+			key := decisionKey{accountID, key}
+			state.Decisions = append(state.Decisions, *newDecisionRecord(key, db.memory.decisions[key]))
+		}
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(db.dir, snapshotFileName)
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(frame(payload)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if err := syncDir(db.dir); err != nil {
+		return err
+	}
+
+	if err := db.wal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := db.wal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	db.records = 0
+	return db.wal.Sync()
+}
+
+func (db *dbFile) loadSnapshot() error {
+	path := filepath.Join(db.dir, snapshotFileName)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	payload, _, err := readFrame(content)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	var state snapshot
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	for _, record := range state.Accounts {
+		db.apply(walRecord{Account: &record})
+	}
+	for _, record := range state.Decisions {
+		db.apply(walRecord{Decision: &record})
+	}
+	db.checkpoint = state.Checkpoint
+	return nil
+}
+
+func (db *dbFile) replay() error {
+	wal, err := openLog(filepath.Join(db.dir, walFileName), func(_ int64, payload []byte) error {
+		var record walRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return err
+		}
+		db.apply(record)
+		db.records++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	db.wal = wal
+	return nil
+}
+
+func (db *dbFile) apply(record walRecord) {
+	if record.Account != nil {
+		db.memory.store(record.Account.toAccount())
+	}
+	if record.Change != nil {
+		acc, err := db.memory.FindAccount(record.Change.AccountID)
+		if err == nil && acc.version == record.Change.Version-1 {
+			db.memory.store(record.Change.apply(acc))
+		}
+		if record.Change.Offset-1 > db.checkpoint {
+			db.checkpoint = record.Change.Offset - 1
+		}
+	}
+	if record.Decision != nil {
+		key, decision := record.Decision.toDecision()
+		db.memory.SaveDecision(key.accountID, key.key, decision)
+	}
+}
+
+// openLog reads every intact frame of the log at path, passing read its position and payload,
+// and truncates the log right after the last one, discarding a partially written or corrupted
+// tail left by a crash. The returned file is positioned at the end of the log, ready for new
+// frames to be appended.
+func openLog(path string, read func(position int64, payload []byte) error) (*os.File, error) {
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := log.Stat()
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	frames := newFrameReader(log, 0, info.Size())
+	for {
+		position := frames.position
+		payload, err := frames.next()
+		if err == errCorruptedFrame || err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = read(position, payload)
+		}
+		if err != nil {
+			log.Close()
+			return nil, err
+		}
+	}
+
+	if frames.position < info.Size() {
+		if err := log.Truncate(frames.position); err != nil {
+			log.Close()
+			return nil, err
+		}
+	}
+	if _, err := log.Seek(frames.position, io.SeekStart); err != nil {
+		log.Close()
+		return nil, err
+	}
+	return log, nil
+}
+
+// frameReader reads frames in order from a section of a log, without loading it whole.
+type frameReader struct {
+	reader   *bufio.Reader
+	position int64
+	end      int64
+}
+
+func newFrameReader(log io.ReaderAt, from int64, to int64) *frameReader {
+	return &frameReader{
+		reader:   bufio.NewReader(io.NewSectionReader(log, from, to-from)),
+		position: from,
+		end:      to,
+	}
+}
+
+// next fails with io.EOF at the end of the section, and with errCorruptedFrame when the
+// frame is torn or does not match its checksum. position is only advanced past intact frames.
+func (r *frameReader) next() ([]byte, error) {
+	if r.position == r.end {
+		return nil, io.EOF
+	}
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r.reader, header); err != nil {
+		return nil, corruptedOr(err)
+	}
+	size := int64(binary.BigEndian.Uint32(header[0:4]))
+	if r.end-r.position-frameHeaderSize < size {
+		return nil, errCorruptedFrame
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, payload); err != nil {
+		return nil, corruptedOr(err)
+	}
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errCorruptedFrame
+	}
+	r.position += frameHeaderSize + size
+	return payload, nil
+}
+
+func corruptedOr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errCorruptedFrame
+	}
+	return err
+}
+
+func appendFrame(log *os.File, payload []byte) error {
+	if _, err := log.Write(frame(payload)); err != nil {
+		return err
+	}
+	return log.Sync()
+}
+
+func frame(payload []byte) []byte {
+	content := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(content[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(content[4:8], crc32.Checksum(payload, crcTable))
+	copy(content[frameHeaderSize:], payload)
+	return content
+}
+
+func readFrame(content []byte) ([]byte, int, error) {
+	if len(content) < frameHeaderSize {
+		return nil, 0, errCorruptedFrame
+	}
+	size := int(binary.BigEndian.Uint32(content[0:4]))
+	if len(content)-frameHeaderSize < size {
+		return nil, 0, errCorruptedFrame
+	}
+	payload := content[frameHeaderSize : frameHeaderSize+size]
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(content[4:8]) {
+		return nil, 0, errCorruptedFrame
+	}
+	return payload, frameHeaderSize + size, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func newAccountRecord(acc Account) *accountRecord {
+	record := &accountRecord{Account: acc, History: acc.history.Transactions(), Version: acc.version}
+	for _, t := range acc.refundable.Transactions() {
+		record.Transactions = append(record.Transactions, transactionRecord{t, t.refunded})
+	}
+	return record
+}
+
+func (r accountRecord) toAccount() Account {
+	acc := r.Account
+	acc.history = NewHistory(r.History...)
+	acc.version = r.Version
+	transactions := make([]Transaction, 0, len(r.Transactions))
+	for _, t := range r.Transactions {
+		tr := t.Transaction
+		tr.refunded = t.Refunded
+		transactions = append(transactions, tr)
+	}
+	acc.refundable = NewRefundable(transactions...)
+	return acc
+}
+
+func newDecisionRecord(key decisionKey, decision Decision) *decisionRecord {
+	record := &decisionRecord{
+		AccountID:  key.accountID,
+		Key:        key.key,
+		Account:    decision.Account,
+		Conversion: decision.Account.conversion,
+		Approval:   decision.Account.approval,
+	}
+	for _, err := range decision.Errors {
+		record.Violations = append(record.Violations, err.Error())
+	}
+	return record
+}
+
+func (r decisionRecord) toDecision() (decisionKey, Decision) {
+	decision := Decision{Account: r.Account}
+	decision.Account.conversion = r.Conversion
+	decision.Account.approval = r.Approval
+	for _, violation := range r.Violations {
+		decision.Errors = append(decision.Errors, errors.New(violation))
+	}
+	return decisionKey{r.AccountID, r.Key}, decision
+}
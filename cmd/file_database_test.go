@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenFileDB(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should recover accounts, history and decision responses after reopening": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			acc := Account{
+				ID:             1,
+				CardStatus:     CardActive,
+				CreditLimit:    100,
+				AvailableLimit: 100,
+			}
+			db.CreateAccount(acc)
+			for _, change := range []Event{
+				{Version: 1, Type: TransactionAuthorized, Transaction: &Transaction{ID: "t1", Merchant: "Acme Corporation", Amount: 20, Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)}},
+				{Version: 2, Type: TransactionAuthorized, Transaction: &Transaction{Merchant: "Acme Corporation", Amount: 5, Time: time.Date(2020, 7, 12, 10, 1, 0, 0, time.UTC)}},
+				{Version: 3, Type: TransactionRefunded, TransactionID: "t1", Amount: 5},
+			} {
+				acc, _ = db.UpdateAccount(change.apply(acc), change)
+			}
+			decision := Decision{Account: acc, Errors: []error{errors.New(DoubledTransaction)}}
+			db.SaveDecision(1, "key", decision)
+			db.Close()
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			found, _ := res.FindAccount(1)
+			assert.Equal(t, acc, found)
+			replayed, _ := res.FindDecision(1, "key")
+			assert.Equal(t, decision.response(), replayed)
+		},
+		"Should report changes that cannot be written to the log while keeping them": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.wal.Close()
+
+			// when
+			_, err := db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+
+			// then
+			var storageErr *StorageError
+			assert.ErrorAs(t, err, &storageErr)
+			assert.EqualError(t, err, StorageUnavailable)
+			found, _ := db.FindAccount(1)
+			assert.Equal(t, Money(100), found.AvailableLimit)
+			assert.Error(t, db.SaveDecision(1, "key", Decision{}))
+		},
+		"Should snapshot the state before writing again after a failed write": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, CreditLimit: 100, AvailableLimit: 100})
+			wal := db.wal
+			db.wal, _ = os.Open(filepath.Join(dir, walFileName))
+			_, failed := db.UpdateAccount(Account{ID: 1}, Event{Version: 1, Type: CreditLimitChanged, CreditLimit: 80})
+			db.wal.Close()
+			db.wal = wal
+
+			// when
+			_, err := db.UpdateAccount(Account{ID: 1}, Event{Version: 2, Type: CreditLimitChanged, CreditLimit: 60})
+			db.Close()
+
+			// then
+			assert.Error(t, failed)
+			assert.NoError(t, err)
+			res, _ := OpenFileDB(dir)
+			found, _ := res.FindAccount(1)
+			assert.Equal(t, Money(60), found.AvailableLimit)
+		},
+		"Should discard a partially written record left by a crash": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.UpdateAccount(Account{ID: 1}, Event{Version: 1, Type: TransactionAuthorized, Transaction: &Transaction{Amount: 20}})
+			db.Close()
+			truncateFile(t, filepath.Join(dir, walFileName), 3)
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			found, _ := res.FindAccount(1)
+			assert.Equal(t, Money(100), found.AvailableLimit)
+		},
+		"Should discard a record with an invalid checksum": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.UpdateAccount(Account{ID: 1}, Event{Version: 1, Type: TransactionAuthorized, Transaction: &Transaction{Amount: 20}})
+			db.Close()
+			path := filepath.Join(dir, walFileName)
+			content, _ := os.ReadFile(path)
+			content[len(content)-2] ^= 0xff
+			_ = os.WriteFile(path, content, 0644)
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			found, _ := res.FindAccount(1)
+			assert.Equal(t, Money(100), found.AvailableLimit)
+		},
+		"Should keep appending after discarding a corrupted record": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.Close()
+			truncateFile(t, filepath.Join(dir, walFileName), 1)
+			db, _ = OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 2, CardStatus: CardActive, AvailableLimit: 200})
+			db.Close()
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			_, err = res.FindAccount(1)
+			assert.Error(t, err)
+			found, _ := res.FindAccount(2)
+			assert.Equal(t, Money(200), found.AvailableLimit)
+		},
+		"Should not open a directory with a corrupted snapshot": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			_ = os.WriteFile(filepath.Join(dir, snapshotFileName), []byte("garbage"), 0644)
+
+			// when
+			_, err := OpenFileDB(dir)
+
+			// then
+			assert.Error(t, err)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestFileDBSnapshot(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should compact the log into a snapshot after the configured interval": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.snapshotInterval = 2
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.CreateAccount(Account{ID: 2, CardStatus: CardActive, AvailableLimit: 200})
+			db.UpdateAccount(Account{ID: 1}, Event{Version: 1, Type: TransactionAuthorized, Transaction: &Transaction{Amount: 10}})
+			db.Close()
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			assert.FileExists(t, filepath.Join(dir, snapshotFileName))
+			assert.Equal(t, 1, res.records)
+			first, _ := res.FindAccount(1)
+			second, _ := res.FindAccount(2)
+			assert.Equal(t, Money(90), first.AvailableLimit)
+			assert.Equal(t, Money(200), second.AvailableLimit)
+		},
+		"Should keep the checkpoint of the changes stored in the snapshot": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.UpdateAccount(Account{ID: 1}, Event{Offset: 3, Version: 1, Type: TransactionAuthorized, Transaction: &Transaction{Amount: 10}})
+			db.Snapshot()
+			db.Close()
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, 0, res.records)
+			assert.Equal(t, 2, res.Checkpoint())
+		},
+		"Should not apply again changes already included in the snapshot": func(t *testing.T) {
+			// given
+			dir := tempDataDir(t)
+			db, _ := OpenFileDB(dir)
+			db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+			db.UpdateAccount(Account{ID: 1}, Event{Version: 1, Type: TransactionAuthorized, Transaction: &Transaction{Amount: 10}})
+			log := filepath.Join(dir, walFileName)
+			content, _ := os.ReadFile(log)
+			db.Snapshot()
+			db.Close()
+			_ = os.WriteFile(log, content, 0644)
+
+			// when
+			res, err := OpenFileDB(dir)
+
+			// then
+			assert.NoError(t, err)
+			found, _ := res.FindAccount(1)
+			assert.Equal(t, Money(90), found.AvailableLimit)
+			assert.Equal(t, 1, found.version)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func tempDataDir(t *testing.T) string {
+	return t.TempDir()
+}
+
+func truncateFile(t *testing.T, path string, bytes int64) {
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-bytes))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCServer struct {
+	UnimplementedAuthorizerServer
+	handler Handler
+}
+
+func NewGRPCServer(h Handler) *GRPCServer {
+	return &GRPCServer{handler: h}
+}
+
+func (s *GRPCServer) Serve(ctx context.Context, listener net.Listener) error {
+	srv := grpc.NewServer()
+	RegisterAuthorizerServer(srv, s)
+
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+
+	return srv.Serve(listener)
+}
+
+func (s *GRPCServer) CreateAccount(_ context.Context, req *CreateAccountRequest) (*AuthorizationResponse, error) {
+	currency, err := ParseCurrency(req.GetAccount().GetCurrency())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	acc, errs := s.dispatch(Account{
+		ID:             int(req.GetAccount().GetAccountId()),
+		CardStatus:     toCardStatus(req.GetAccount()),
+		Currency:       currency,
+		AvailableLimit: toMoney(req.GetAccount().GetAvailableLimit(), req.GetAccount().GetAvailableLimitMinor()),
+	})
+	return toAuthorizationResponse(acc, errs), nil
+}
+
+func (s *GRPCServer) Authorize(_ context.Context, req *AuthorizeRequest) (*AuthorizationResponse, error) {
+	tr, err := toTransaction(req.GetTransaction())
+	if err != nil {
+		return nil, err
+	}
+
+	acc, errs := s.dispatch(tr)
+	return toAuthorizationResponse(acc, errs), nil
+}
+
+func (s *GRPCServer) AuthorizeStream(stream Authorizer_AuthorizeStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		tr, err := toTransaction(req.GetTransaction())
+		if err != nil {
+			return err
+		}
+
+		acc, errs := s.dispatch(tr)
+		err = stream.Send(toAuthorizationResponse(acc, errs))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *GRPCServer) dispatch(request accountRequest) (Account, []error) {
+	decision := <-s.handler.Submit(request)
+	return decision.Account, decision.Errors
+}
+
+// toTransaction fails with InvalidArgument on an unsupported currency, as HTTP answers
+// such bodies with invalid-input.
+func toTransaction(tr *TransactionPayload) (Transaction, error) {
+	currency, err := ParseCurrency(tr.GetCurrency())
+	if err != nil {
+		return Transaction{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return Transaction{
+		AccountID:      int(tr.GetAccountId()),
+		Merchant:       tr.GetMerchant(),
+		Amount:         toMoney(tr.GetAmount(), tr.GetAmountMinor()),
+		Currency:       currency,
+		Time:           tr.GetTime().AsTime(),
+		IdempotencyKey: tr.GetIdempotencyKey(),
+	}, nil
+}
+
+// toMoney takes the exact amount in minor units when informed, or else the whole units.
+func toMoney(units int64, minor int64) Money {
+	if minor != 0 {
+		return Money(minor)
+	}
+	return Units(units)
+}
+
+func toCardStatus(acc *AccountPayload) CardStatus {
+	if acc.GetCardStatus() != "" {
+		return CardStatus(acc.GetCardStatus())
+	}
+	if acc.GetActiveCard() {
+		return CardActive
+	}
+	return CardInactive
+}
+
+func toAuthorizationResponse(acc Account, errs []error) *AuthorizationResponse {
+	res := &AuthorizationResponse{
+		Account: &AccountPayload{
+			AccountId:           int64(acc.ID),
+			ActiveCard:          acc.ActiveCard(),
+			CardStatus:          string(acc.CardStatus),
+			AvailableLimit:      int64(acc.AvailableLimit / minorUnits),
+			AvailableLimitMinor: int64(acc.AvailableLimit),
+			Currency:            string(acc.Currency.orDefault()),
+		},
+	}
+	for _, err := range errs {
+		res.Violations = append(res.Violations, violationCodes[err.Error()])
+		res.ViolationCodes = append(res.ViolationCodes, err.Error())
+	}
+	return res
+}
+
+var violationCodes = map[string]Violation{
+	AccountAlreadyInitialized:   Violation_ACCOUNT_ALREADY_INITIALIZED,
+	AccountNotInitialized:       Violation_ACCOUNT_NOT_INITIALIZED,
+	InsufficientLimit:           Violation_INSUFFICIENT_LIMIT,
+	CardNotActive:               Violation_CARD_NOT_ACTIVE,
+	HighFrequencySmallInterval:  Violation_HIGH_FREQUENCY_SMALL_INTERVAL,
+	DoubledTransaction:          Violation_DOUBLED_TRANSACTION,
+	CardIsBlocked:               Violation_CARD_BLOCKED,
+	CardIsClosed:                Violation_CARD_CLOSED,
+	CardAlreadyActive:           Violation_CARD_ALREADY_ACTIVE,
+	CardAlreadyBlocked:          Violation_CARD_ALREADY_BLOCKED,
+	CardNotBlocked:              Violation_CARD_NOT_BLOCKED,
+	BlockReasonRequired:         Violation_BLOCK_REASON_REQUIRED,
+	InvalidCardAction:           Violation_INVALID_CARD_ACTION,
+	InvalidCardStatus:           Violation_INVALID_CARD_STATUS,
+	OriginalTransactionNotFound: Violation_ORIGINAL_TRANSACTION_NOT_FOUND,
+	RefundExceedsOriginal:       Violation_REFUND_EXCEEDS_ORIGINAL,
+	InvalidAmount:               Violation_INVALID_AMOUNT,
+	CreditLimitBelowUsage:       Violation_CREDIT_LIMIT_BELOW_USAGE,
+	AvailableLimitAboveCredit:   Violation_AVAILABLE_LIMIT_ABOVE_CREDIT_LIMIT,
+	AccountVersionConflict:      Violation_ACCOUNT_VERSION_CONFLICT,
+	StorageUnavailable:          Violation_STORAGE_UNAVAILABLE,
+	CurrencyMismatch:            Violation_CURRENCY_MISMATCH,
+	AmountOverflow:              Violation_AMOUNT_OVERFLOW,
+	TransactionIDRequired:       Violation_TRANSACTION_ID_REQUIRED,
+	DuplicateTransactionID:      Violation_DUPLICATE_TRANSACTION_ID,
+	HoldNotFound:                Violation_HOLD_NOT_FOUND,
+	HoldNotCaptured:             Violation_HOLD_NOT_CAPTURED,
+	PartiallyApproved:           Violation_PARTIALLY_APPROVED,
+	InvalidTimeZone:             Violation_INVALID_TIME_ZONE,
+	TransactionLimitExceeded:    Violation_TRANSACTION_LIMIT_EXCEEDED,
+	DailyLimitExceeded:          Violation_DAILY_LIMIT_EXCEEDED,
+	MonthlyLimitExceeded:        Violation_MONTHLY_LIMIT_EXCEEDED,
+	InvalidVelocityLimits:       Violation_INVALID_VELOCITY_LIMITS,
+	HighAmountSmallInterval:     Violation_HIGH_AMOUNT_SMALL_INTERVAL,
+	ManyMerchantsSmallInterval:  Violation_MANY_MERCHANTS_SMALL_INTERVAL,
+	MerchantBlocked:             Violation_MERCHANT_BLOCKED,
+	MerchantNotAllowed:          Violation_MERCHANT_NOT_ALLOWED,
+	MerchantPatternRequired:     Violation_MERCHANT_PATTERN_REQUIRED,
+	MerchantAlreadyListed:       Violation_MERCHANT_ALREADY_LISTED,
+	MerchantNotListed:           Violation_MERCHANT_NOT_LISTED,
+	InvalidMerchantAction:       Violation_INVALID_MERCHANT_ACTION,
+	InvalidMCC:                  Violation_INVALID_MCC,
+	UnknownCategory:             Violation_UNKNOWN_CATEGORY,
+	CategoryBlocked:             Violation_CATEGORY_BLOCKED,
+	CategoryNotAllowed:          Violation_CATEGORY_NOT_ALLOWED,
+	CategoryLimitExceeded:       Violation_CATEGORY_LIMIT_EXCEEDED,
+	InvalidSimilarityExemption:  Violation_INVALID_SIMILARITY_EXEMPTION,
+	InvalidInput:                Violation_INVALID_INPUT,
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGRPCServer(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should create account": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+
+			// when
+			res, err := client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{ActiveCard: true, AvailableLimit: 100},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.True(t, res.GetAccount().GetActiveCard())
+			assert.Equal(t, int64(100), res.GetAccount().GetAvailableLimit())
+			assert.Empty(t, res.GetViolations())
+		},
+		"Should not create account when an account is already initialized": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+			_, _ = client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{ActiveCard: true, AvailableLimit: 100},
+			})
+
+			// when
+			res, err := client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{ActiveCard: false, AvailableLimit: 200},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, int64(100), res.GetAccount().GetAvailableLimit())
+			assert.Equal(t, []Violation{Violation_ACCOUNT_ALREADY_INITIALIZED}, res.GetViolations())
+			assert.Equal(t, []string{AccountAlreadyInitialized}, res.GetViolationCodes())
+		},
+		"Should not create account with unknown card status": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+
+			// when
+			res, err := client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{CardStatus: "frozen", AvailableLimit: 100},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, []string{InvalidCardStatus}, res.GetViolationCodes())
+		},
+		"Should authorize transaction": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+			_, _ = client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{ActiveCard: true, AvailableLimit: 100},
+			})
+
+			// when
+			res, err := client.Authorize(context.Background(), &AuthorizeRequest{
+				Transaction: &TransactionPayload{
+					Merchant: "Acme Corporation",
+					Amount:   20,
+					Time:     timestamppb.New(time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)),
+				},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, int64(80), res.GetAccount().GetAvailableLimit())
+			assert.Empty(t, res.GetViolations())
+		},
+		"Should authorize transaction with exact amounts": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+			_, _ = client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{ActiveCard: true, AvailableLimitMinor: 10050, Currency: "brl"},
+			})
+
+			// when
+			res, err := client.Authorize(context.Background(), &AuthorizeRequest{
+				Transaction: &TransactionPayload{
+					Merchant:    "Acme Corporation",
+					AmountMinor: 2099,
+					Time:        timestamppb.New(time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)),
+				},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, int64(7951), res.GetAccount().GetAvailableLimitMinor())
+			assert.Equal(t, int64(79), res.GetAccount().GetAvailableLimit())
+			assert.Equal(t, string(BRL), res.GetAccount().GetCurrency())
+			assert.Empty(t, res.GetViolations())
+		},
+		"Should not authorize transaction with unsupported currency": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+
+			// when
+			_, err := client.Authorize(context.Background(), &AuthorizeRequest{
+				Transaction: &TransactionPayload{
+					Merchant: "Acme Corporation",
+					Amount:   20,
+					Currency: "XYZ",
+					Time:     timestamppb.New(time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)),
+				},
+			})
+
+			// then
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		},
+		"Should authorize transactions on a stream": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+			_, _ = client.CreateAccount(context.Background(), &CreateAccountRequest{
+				Account: &AccountPayload{ActiveCard: true, AvailableLimit: 100},
+			})
+			stream, err := client.AuthorizeStream(context.Background())
+			assert.NoError(t, err)
+			tr := &TransactionPayload{
+				Merchant: "Acme Corporation",
+				Amount:   20,
+				Time:     timestamppb.New(time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)),
+			}
+
+			// when
+			assert.NoError(t, stream.Send(&AuthorizeRequest{Transaction: tr}))
+			first, err := stream.Recv()
+			assert.NoError(t, err)
+			assert.NoError(t, stream.Send(&AuthorizeRequest{Transaction: tr}))
+			second, err := stream.Recv()
+			assert.NoError(t, err)
+			assert.NoError(t, stream.CloseSend())
+
+			// then
+			assert.Equal(t, int64(80), first.GetAccount().GetAvailableLimit())
+			assert.Empty(t, first.GetViolations())
+			assert.Equal(t, int64(80), second.GetAccount().GetAvailableLimit())
+			assert.Equal(t, []Violation{Violation_DOUBLED_TRANSACTION}, second.GetViolations())
+		},
+		"Should not authorize transaction for unknown account": func(t *testing.T) {
+			// given
+			client := startGRPCServer(t)
+
+			// when
+			res, err := client.Authorize(context.Background(), &AuthorizeRequest{
+				Transaction: &TransactionPayload{
+					AccountId: 5,
+					Merchant:  "Acme Corporation",
+					Amount:    20,
+					Time:      timestamppb.New(time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)),
+				},
+			})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), res.GetAccount().GetAccountId())
+			assert.Equal(t, []Violation{Violation_ACCOUNT_NOT_INITIALIZED}, res.GetViolations())
+		},
+		"Should map every violation code to its own Violation value": func(t *testing.T) {
+			// given
+			seen := map[Violation]string{}
+
+			for code, violation := range violationCodes {
+				// when
+				name := strings.ToLower(strings.ReplaceAll(violation.String(), "_", "-"))
+
+				// then
+				assert.Equal(t, code, name)
+				assert.NotContains(t, seen, violation, "%s and %s share a value", code, seen[violation])
+				seen[violation] = code
+			}
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func startGRPCServer(t *testing.T) AuthorizerClient {
+	listener := bufconn.Listen(1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = NewGRPCServer(newTestHandler()).Serve(ctx, listener)
+	}()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+	})
+	return NewAuthorizerClient(conn)
+}
@@ -3,65 +3,208 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 )
 
 type Handler struct {
 	db             DB
+	events         EventStore
 	accountHandler AccountHandler
+	pool           *Pool
+}
+
+// accountRequest is a request handled against a single account, which is also the account
+// ordering it among concurrent requests.
+type accountRequest interface {
+	accountID() int
 }
 
 type AccountHandler interface {
 	Initialize(Account) (Account, []error)
 	Authorize(Account, Transaction) (Account, []error)
+	Refund(Account, Refund) (Account, []error)
+	Reverse(Account, Reversal) (Account, []error)
+	Capture(Account, Capture) (Account, []error)
+	Update(Account, accountChange) (Account, []error)
 }
 
-func (h *Handler) Decode(reader io.Reader) interface{} {
-	type payload struct {
-		Account     *Account     `json:"account"`
-		Transaction *Transaction `json:"transaction"`
+// operations lists the requests understood on the input under the key naming each of them, in
+// the order they are looked for.
+var operations = []struct {
+	key    string
+	decode func(json.RawMessage) (accountRequest, error)
+}{
+	{"account", decodeRequest[Account]},
+	{"transaction", decodeRequest[Transaction]},
+	{"refund", decodeRequest[Refund]},
+	{"reversal", decodeRequest[Reversal]},
+	{"capture", decodeRequest[Capture]},
+	{"card", decodeRequest[CardOperation]},
+	{"accountUpdate", decodeRequest[AccountUpdate]},
+	{"spendingLimits", decodeRequest[SpendingLimitsUpdate]},
+	{"velocityLimits", decodeRequest[VelocityLimitsUpdate]},
+	{"merchants", decodeRequest[MerchantOperation]},
+	{"categories", decodeRequest[CategoryPolicyUpdate]},
+	{"similarityExemptions", decodeRequest[SimilarityExemptionsUpdate]},
+}
+
+func decodeRequest[T accountRequest](raw json.RawMessage) (accountRequest, error) {
+	var request T
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (h *Handler) Decode(reader io.Reader) (accountRequest, error) {
+	var input map[string]json.RawMessage
+	decoder := json.NewDecoder(reader)
+	if err := decoder.Decode(&input); err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return nil, errTrailingData
 	}
 
-	var input payload
-	_ = json.NewDecoder(reader).Decode(&input)
+	for _, operation := range operations {
+		if raw, ok := input[operation.key]; ok && string(raw) != "null" {
+			return operation.decode(raw)
+		}
+	}
+	return nil, errUnknownOperation
+}
 
-	if input.Account != nil {
-		return *input.Account
+func (h *Handler) Dispatch(request accountRequest) (Account, []error) {
+	if request == nil {
+		return Account{}, nil
 	}
-	if input.Transaction != nil {
-		return *input.Transaction
+	if acc, ok := request.(Account); ok {
+		return h.accountHandler.Initialize(acc)
+	}
+	return h.withAccount(request.accountID(), func(acc Account) (Account, []error) {
+		switch req := request.(type) {
+		case Transaction:
+			return h.accountHandler.Authorize(acc, req)
+		case Refund:
+			return h.accountHandler.Refund(acc, req)
+		case Reversal:
+			return h.accountHandler.Reverse(acc, req)
+		case Capture:
+			return h.accountHandler.Capture(acc, req)
+		case accountChange:
+			return h.accountHandler.Update(acc, req)
+		default:
+			return acc, nil
+		}
+	})
+}
+
+// Submit queues the request on the worker pool, or dispatches it right away when there is none.
+func (h *Handler) Submit(request accountRequest) <-chan Decision {
+	if h.pool != nil {
+		return h.pool.Submit(request)
+	}
+	decision := make(chan Decision, 1)
+	acc, errs := h.Dispatch(request)
+	decision <- Decision{Account: acc, Errors: errs}
+	return decision
+}
+
+// withAccount evaluates the operation again against a fresh copy of the account whenever
+// it changed in the meantime, giving up after MaxConflictRetries attempts.
+func (h *Handler) withAccount(id int, operation func(Account) (Account, []error)) (Account, []error) {
+	for attempt := 1; ; attempt++ {
+		acc, err := h.db.FindAccount(id)
+		if err != nil {
+			return Account{ID: id}, []error{errors.New(AccountNotInitialized)}
+		}
+		acc, errs := operation(acc)
+		if attempt == MaxConflictRetries || !hasVersionConflict(errs) {
+			return acc, errs
+		}
 	}
-	return nil
 }
 
-func (h *Handler) Dispatch(request interface{}) (Account, []error) {
-	switch req := request.(type) {
-	case Account:
-		return h.accountHandler.Initialize(req)
-	case Transaction:
-		acc, _ := h.db.CurrentAccount()
-		return h.accountHandler.Authorize(acc, req)
-	default:
-		acc, _ := h.db.CurrentAccount()
-		return acc, nil
+func hasVersionConflict(errs []error) bool {
+	for _, err := range errs {
+		if errors.Is(err, ErrVersionConflict) {
+			return true
+		}
 	}
+	return false
 }
 
 func (h *Handler) Encode(acc Account, errs []error) *bytes.Buffer {
+	type input struct {
+		Line   int    `json:"line,omitempty"`
+		Reason string `json:"reason"`
+	}
 	type payload struct {
-		Account    *Account `json:"account"`
-		Violations []string `json:"violations"`
+		Account    *Account    `json:"account"`
+		Conversion *Conversion `json:"conversion,omitempty"`
+		Approval   *Approval   `json:"approval,omitempty"`
+		Violations []string    `json:"violations"`
+		Input      *input      `json:"input,omitempty"`
 	}
 
 	var output = payload{
 		Account:    &acc,
+		Conversion: acc.conversion,
+		Approval:   acc.approval,
 		Violations: []string{},
 	}
 	for _, err := range errs {
 		output.Violations = append(output.Violations, err.Error())
+
+		var inputErr *InputError
+		if errors.As(err, &inputErr) {
+			output.Input = &input{
+				Line:   inputErr.Line,
+				Reason: inputErr.Err.Error(),
+			}
+		}
 	}
 
 	buffer := &bytes.Buffer{}
 	_ = json.NewEncoder(buffer).Encode(&output)
 	return buffer
 }
+
+// Close waits for pending requests and releases the files kept open by a persistent storage.
+func (h *Handler) Close() error {
+	if h.pool != nil {
+		h.pool.Close()
+	}
+	for _, storage := range []interface{}{h.db, h.events} {
+		if closer, ok := storage.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	errUnknownOperation = errors.New("unknown operation")
+	errTrailingData     = errors.New("unexpected data after the operation")
+)
+
+type InputError struct {
+	Line int
+	Err  error
+}
+
+func (e *InputError) Error() string {
+	return InvalidInput
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	InvalidInput       = "invalid-input"
+	MaxConflictRetries = 3
+)
@@ -19,12 +19,27 @@ func TestDecode(t *testing.T) {
 			stdin.Write([]byte(`{ "account": { "activeCard": true, "availableLimit": 100 } }`))
 
 			// when
-			res := h.Decode(&stdin)
+			res, err := h.Decode(&stdin)
 
 			// then
+			assert.NoError(t, err)
 			acc := res.(Account)
-			assert.Equal(t, true, acc.ActiveCard)
-			assert.Equal(t, 100, acc.AvailableLimit)
+			assert.Equal(t, CardActive, acc.CardStatus)
+			assert.Equal(t, Units(100), acc.AvailableLimit)
+		},
+		"Should decode account with identifier": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "account": { "accountId": 7, "activeCard": true, "availableLimit": 100 } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			acc := res.(Account)
+			assert.Equal(t, 7, acc.ID)
 		},
 		"Should decode transaction": func(t *testing.T) {
 			// given
@@ -33,14 +48,168 @@ func TestDecode(t *testing.T) {
 			stdin.Write([]byte(`{ "transaction": { "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" } }`))
 
 			// when
-			res := h.Decode(&stdin)
+			res, err := h.Decode(&stdin)
 
 			// then
+			assert.NoError(t, err)
 			tr := res.(Transaction)
 			assert.Equal(t, "Acme Corporation", tr.Merchant)
-			assert.Equal(t, 20, tr.Amount)
+			assert.Equal(t, Units(20), tr.Amount)
 			assert.NotEmpty(t, tr.Time)
 		},
+		"Should decode refund": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "refund": { "accountId": 1, "transactionId": "t1", "amount": 10, "time": "2020-07-12T10:00:00.000Z" } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			rf := res.(Refund)
+			assert.Equal(t, 1, rf.AccountID)
+			assert.Equal(t, "t1", rf.TransactionID)
+			assert.Equal(t, Units(10), rf.Amount)
+		},
+		"Should decode reversal": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "reversal": { "transactionId": "t1", "time": "2020-07-12T10:00:00.000Z" } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			rv := res.(Reversal)
+			assert.Equal(t, "t1", rv.TransactionID)
+		},
+		"Should decode capture": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "capture": { "accountId": 1, "transactionId": "t1", "amount": "12.50", "time": "2020-07-12T10:00:00.000Z" } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, Capture{
+				AccountID:     1,
+				TransactionID: "t1",
+				Amount:        1250,
+				Time:          time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC),
+			}, res)
+		},
+		"Should decode card operation": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "card": { "accountId": 1, "action": "block", "reason": "lost card" } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, CardOperation{AccountID: 1, Action: BlockCard, Reason: "lost card"}, res)
+		},
+		"Should decode account update": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "accountUpdate": { "accountId": 1, "creditLimit": 500 } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, AccountUpdate{AccountID: 1, CreditLimit: Units(500)}, res)
+		},
+		"Should decode spending limits update": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "spendingLimits": { "accountId": 1, "daily": 100, "monthly": "1000.50", "timeZone": "America/Sao_Paulo" } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, SpendingLimitsUpdate{AccountID: 1, SpendingLimits: SpendingLimits{
+				Daily:    Units(100),
+				Monthly:  100050,
+				TimeZone: "America/Sao_Paulo",
+			}}, res)
+		},
+		"Should decode velocity limits update": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "velocityLimits": { "accountId": 1, "maxAmount": 500, "amountIntervalMinutes": 10, "maxMerchants": 3, "merchantIntervalMinutes": 5 } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, VelocityLimitsUpdate{AccountID: 1, VelocityLimits: VelocityLimits{
+				MaxAmount:               Units(500),
+				AmountIntervalMinutes:   10,
+				MaxMerchants:            3,
+				MerchantIntervalMinutes: 5,
+			}}, res)
+		},
+		"Should decode merchant operation": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "merchants": { "accountId": 1, "action": "block", "pattern": "bet*" } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, MerchantOperation{AccountID: 1, Action: BlockMerchant, Pattern: "bet*"}, res)
+		},
+		"Should decode category policy update": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "categories": { "accountId": 1, "allowed": ["travel"], "limits": { "travel": { "monthly": 5000 } } } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, CategoryPolicyUpdate{AccountID: 1, CategoryPolicy: CategoryPolicy{
+				Allowed: []string{"travel"},
+				Limits:  map[string]SpendingLimits{"travel": {Monthly: Units(5000)}},
+			}}, res)
+		},
+		"Should decode similarity exemptions update": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "similarityExemptions": { "accountId": 1, "exemptions": [{ "merchant": "City Transit*", "maxSimilarityPerInterval": 4, "intervalMinutes": 10 }] } }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, SimilarityExemptionsUpdate{AccountID: 1, Exemptions: []SimilarityExemption{
+				{Merchant: "City Transit*", MaxSimilarityPerInterval: 4, IntervalMinutes: 10},
+			}}, res)
+		},
 		"Should not decode unknown payload": func(t *testing.T) {
 			// given
 			h := Handler{}
@@ -48,9 +217,36 @@ func TestDecode(t *testing.T) {
 			stdin.Write([]byte(`{ "unknown": { "command": "here" } }`))
 
 			// when
-			res := h.Decode(&stdin)
+			res, err := h.Decode(&stdin)
 
 			// then
+			assert.Equal(t, errUnknownOperation, err)
+			assert.Nil(t, res)
+		},
+		"Should not decode trailing data after the operation": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "account": { "activeCard": true, "availableLimit": 100 } } { "account": {} }`))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.Equal(t, errTrailingData, err)
+			assert.Nil(t, res)
+		},
+		"Should not decode malformed payload": func(t *testing.T) {
+			// given
+			h := Handler{}
+			var stdin bytes.Buffer
+			stdin.Write([]byte(`{ "account": { "activeCard": true, `))
+
+			// when
+			res, err := h.Decode(&stdin)
+
+			// then
+			assert.Error(t, err)
 			assert.Empty(t, res)
 		},
 	}
@@ -68,8 +264,8 @@ func TestEncode(t *testing.T) {
 			// given
 			h := Handler{}
 			acc := Account{
-				ActiveCard:     true,
-				AvailableLimit: 100,
+				CardStatus:     CardActive,
+				AvailableLimit: Units(100),
 			}
 			errs := []error{
 				errors.New("this-is-an-error"),
@@ -79,7 +275,46 @@ func TestEncode(t *testing.T) {
 			res := h.Encode(acc, errs)
 
 			// then
-			assert.JSONEq(t, `{"account":{"activeCard":true,"availableLimit":100},"violations":["this-is-an-error"]}`, res.String())
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","availableLimit":100},"violations":["this-is-an-error"]}`, res.String())
+		},
+		"Should encode response with the conversion of a foreign transaction": func(t *testing.T) {
+			// given
+			h := Handler{}
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: Units(48),
+				conversion: &Conversion{
+					OriginalAmount:   Units(10),
+					OriginalCurrency: USD,
+					Rate:             5432100,
+					ConvertedAmount:  5432,
+					Fee:              217,
+					Currency:         BRL,
+				},
+			}
+
+			// when
+			res := h.Encode(acc, nil)
+
+			// then
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","availableLimit":48},"conversion":{"originalAmount":10,"originalCurrency":"USD","rate":5.4321,"convertedAmount":54.32,"fee":2.17,"currency":"BRL"},"violations":[]}`, res.String())
+		},
+		"Should encode response with invalid input details": func(t *testing.T) {
+			// given
+			h := Handler{}
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: Units(100),
+			}
+			errs := []error{
+				&InputError{Line: 3, Err: errors.New("unexpected EOF")},
+			}
+
+			// when
+			res := h.Encode(acc, errs)
+
+			// then
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","availableLimit":100},"violations":["invalid-input"],"input":{"line":3,"reason":"unexpected EOF"}}`, res.String())
 		},
 	}
 
@@ -103,7 +338,7 @@ func TestDispatch(t *testing.T) {
 		"Should dispatch initialize account request": func(t *testing.T) {
 			// given
 			acc := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 			accMock.On("Initialize", acc).Return(acc, nil)
@@ -119,7 +354,7 @@ func TestDispatch(t *testing.T) {
 		"Should dispatch authorize transaction request": func(t *testing.T) {
 			// given
 			acc := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
 			tr := Transaction{
@@ -127,7 +362,7 @@ func TestDispatch(t *testing.T) {
 				Amount:   100,
 				Time:     time.Now(),
 			}
-			dbMock.On("CurrentAccount").Return(acc, nil)
+			dbMock.On("FindAccount", 0).Return(acc, nil)
 			accMock.On("Authorize", acc, tr)
 
 			// when
@@ -138,21 +373,260 @@ func TestDispatch(t *testing.T) {
 			assert.Equal(t, acc, res)
 			assert.Empty(t, errs)
 		},
-		"Should reach fallback for unknown request": func(t *testing.T) {
+		"Should dispatch refund request": func(t *testing.T) {
 			// given
 			acc := Account{
-				ActiveCard:     true,
+				CardStatus:     CardActive,
 				AvailableLimit: 100,
 			}
-			dbMock.On("CurrentAccount").Return(acc, nil)
+			rf := Refund{TransactionID: "t1", Amount: 10}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Refund", acc, rf)
 
 			// when
-			res, errs := h.Dispatch(nil)
+			res, errs := h.Dispatch(rf)
+
+			// then
+			accMock.AssertNumberOfCalls(t, "Refund", 1)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch reversal request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			rv := Reversal{TransactionID: "t1"}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Reverse", acc, rv)
+
+			// when
+			res, errs := h.Dispatch(rv)
+
+			// then
+			accMock.AssertNumberOfCalls(t, "Reverse", 1)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch capture request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			cp := Capture{TransactionID: "t1"}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Capture", acc, cp)
+
+			// when
+			res, errs := h.Dispatch(cp)
+
+			// then
+			accMock.AssertNumberOfCalls(t, "Capture", 1)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch card operation request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			op := CardOperation{Action: CloseCard}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, op)
+
+			// when
+			res, errs := h.Dispatch(op)
+
+			// then
+			accMock.AssertCalled(t, "Update", acc, op)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch account update request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			au := AccountUpdate{CreditLimit: 500}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, au)
+
+			// when
+			res, errs := h.Dispatch(au)
 
 			// then
+			accMock.AssertCalled(t, "Update", acc, au)
 			assert.Equal(t, acc, res)
 			assert.Empty(t, errs)
 		},
+		"Should dispatch spending limits update request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			su := SpendingLimitsUpdate{SpendingLimits: SpendingLimits{Daily: 50}}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, su)
+
+			// when
+			res, errs := h.Dispatch(su)
+
+			// then
+			accMock.AssertCalled(t, "Update", acc, su)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch velocity limits update request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			vu := VelocityLimitsUpdate{VelocityLimits: VelocityLimits{MaxMerchants: 3, MerchantIntervalMinutes: 5}}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, vu)
+
+			// when
+			res, errs := h.Dispatch(vu)
+
+			// then
+			accMock.AssertCalled(t, "Update", acc, vu)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch merchant operation request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			op := MerchantOperation{Action: AllowListedOnly}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, op)
+
+			// when
+			res, errs := h.Dispatch(op)
+
+			// then
+			accMock.AssertCalled(t, "Update", acc, op)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch category policy update request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			cu := CategoryPolicyUpdate{CategoryPolicy: CategoryPolicy{Blocked: []string{"cash-advance"}}}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, cu)
+
+			// when
+			res, errs := h.Dispatch(cu)
+
+			// then
+			accMock.AssertCalled(t, "Update", acc, cu)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should dispatch similarity exemptions update request": func(t *testing.T) {
+			// given
+			acc := Account{
+				CardStatus:     CardActive,
+				AvailableLimit: 100,
+			}
+			eu := SimilarityExemptionsUpdate{Exemptions: []SimilarityExemption{{Merchant: "Vending*", MaxSimilarityPerInterval: 3, IntervalMinutes: 5}}}
+			dbMock.On("FindAccount", 0).Return(acc, nil)
+			accMock.On("Update", acc, eu)
+
+			// when
+			res, errs := h.Dispatch(eu)
+
+			// then
+			accMock.AssertCalled(t, "Update", acc, eu)
+			assert.Equal(t, acc, res)
+			assert.Empty(t, errs)
+		},
+		"Should not dispatch authorize transaction request for unknown account": func(t *testing.T) {
+			// given
+			tr := Transaction{
+				AccountID: 2,
+				Merchant:  "Acme Corporation",
+				Amount:    100,
+				Time:      time.Now(),
+			}
+			dbMock.On("FindAccount", 2).Return(Account{}, errors.New("account not found"))
+
+			// when
+			res, errs := h.Dispatch(tr)
+
+			// then
+			assert.Equal(t, Account{ID: 2}, res)
+			assert.Len(t, errs, 1)
+			assert.Contains(t, errs, errors.New(AccountNotInitialized))
+		},
+		"Should reach fallback for unknown request": func(t *testing.T) {
+			// when
+			res, errs := h.Dispatch(nil)
+
+			// then
+			assert.Empty(t, res)
+			assert.Empty(t, errs)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestWithAccount(t *testing.T) {
+	// setup
+	db := NewMemoryDB()
+	db.CreateAccount(Account{ID: 1, CardStatus: CardActive, AvailableLimit: 100})
+	h := Handler{db: db}
+
+	tests := map[string]func(*testing.T){
+		"Should evaluate operation again after a version conflict": func(t *testing.T) {
+			// given
+			attempts := 0
+
+			// when
+			res, errs := h.withAccount(1, func(acc Account) (Account, []error) {
+				attempts++
+				if attempts == 1 {
+					return acc, []error{ErrVersionConflict}
+				}
+				return acc, nil
+			})
+
+			// then
+			assert.Equal(t, 2, attempts)
+			assert.Equal(t, Money(100), res.AvailableLimit)
+			assert.Empty(t, errs)
+		},
+		"Should give up after too many version conflicts": func(t *testing.T) {
+			// given
+			attempts := 0
+
+			// when
+			_, errs := h.withAccount(1, func(acc Account) (Account, []error) {
+				attempts++
+				return acc, []error{ErrVersionConflict}
+			})
+
+			// then
+			assert.Equal(t, MaxConflictRetries, attempts)
+			assert.Equal(t, []error{errors.New(AccountVersionConflict)}, errs)
+		},
 	}
 
 	for name, run := range tests {
@@ -175,3 +649,23 @@ func (h *accountHandlerMock) Authorize(acc Account, tr Transaction) (Account, []
 	_ = h.Called(acc, tr)
 	return acc, nil
 }
+
+func (h *accountHandlerMock) Refund(acc Account, rf Refund) (Account, []error) {
+	_ = h.Called(acc, rf)
+	return acc, nil
+}
+
+func (h *accountHandlerMock) Reverse(acc Account, rv Reversal) (Account, []error) {
+	_ = h.Called(acc, rv)
+	return acc, nil
+}
+
+func (h *accountHandlerMock) Capture(acc Account, cp Capture) (Account, []error) {
+	_ = h.Called(acc, cp)
+	return acc, nil
+}
+
+func (h *accountHandlerMock) Update(acc Account, change accountChange) (Account, []error) {
+	_ = h.Called(acc, change)
+	return acc, nil
+}
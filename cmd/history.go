@@ -0,0 +1,151 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// History keeps the authorized transactions still relevant to the velocity rules, ordered by time,
+// along with their times indexed by merchant and amount so similar transactions are counted without
+// scanning the others. A History is never changed in place: every change returns a new one, so
+// accounts holding it can keep being copied as values.
+type History struct {
+	transactions []Transaction
+	similar      map[similarityKey][]time.Time
+}
+
+type similarityKey struct {
+	merchant string
+	amount   Money
+}
+
+func similarityKeyOf(tr Transaction) similarityKey {
+	return similarityKey{tr.Merchant, tr.Amount}
+}
+
+func NewHistory(transactions ...Transaction) History {
+	if len(transactions) == 0 {
+		return History{}
+	}
+	sorted := append([]Transaction{}, transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+	return History{transactions: sorted, similar: indexSimilar(sorted)}
+}
+
+func (h History) Len() int {
+	return len(h.transactions)
+}
+
+// Transactions returns every transaction kept, ordered by time.
+func (h History) Transactions() []Transaction {
+	return append([]Transaction{}, h.transactions...)
+}
+
+// Between returns the transactions that happened within from and to, both inclusive.
+func (h History) Between(from time.Time, to time.Time) []Transaction {
+	return h.transactions[searchTransactions(h.transactions, from):searchTransactionsAfter(h.transactions, to)]
+}
+
+// after returns the transactions that happened at or after from.
+func (h History) after(from time.Time) []Transaction {
+	return h.transactions[searchTransactions(h.transactions, from):]
+}
+
+// countSince counts every transaction at or after from, along with those similar to tr.
+func (h History) countSince(tr Transaction, from time.Time) matches {
+	similar := h.similar[similarityKeyOf(tr)]
+	return matches{
+		frequency:  len(h.transactions) - searchTransactions(h.transactions, from),
+		similarity: len(similar) - searchTimes(similar, from),
+	}
+}
+
+// with returns a copy of the history holding tr as well.
+func (h History) with(tr Transaction) History {
+	i := searchTransactionsAfter(h.transactions, tr.Time)
+	transactions := make([]Transaction, 0, len(h.transactions)+1)
+	transactions = append(transactions, h.transactions[:i]...)
+	transactions = append(transactions, tr)
+	transactions = append(transactions, h.transactions[i:]...)
+
+	similar := make(map[similarityKey][]time.Time, len(h.similar)+1)
+	for key, times := range h.similar {
+		similar[key] = times
+	}
+	key := similarityKeyOf(tr)
+	times := h.similar[key]
+	j := searchTimesAfter(times, tr.Time)
+	indexed := make([]time.Time, 0, len(times)+1)
+	indexed = append(indexed, times[:j]...)
+	indexed = append(indexed, tr.Time)
+	similar[key] = append(indexed, times[j:]...)
+
+	return History{transactions: transactions, similar: similar}
+}
+
+// without returns a copy of the history missing the transaction with the given identifier.
+func (h History) without(id string) History {
+	for i, t := range h.transactions {
+		if id != "" && t.ID == id {
+			transactions := make([]Transaction, 0, len(h.transactions)-1)
+			transactions = append(transactions, h.transactions[:i]...)
+			return NewHistory(append(transactions, h.transactions[i+1:]...)...)
+		}
+	}
+	return h
+}
+
+// since evicts the transactions that happened before from. The remaining ones are shared with
+// the original history, which is safe as neither of them is ever changed in place.
+func (h History) since(from time.Time) History {
+	first := searchTransactions(h.transactions, from)
+	switch first {
+	case 0:
+		return h
+	case len(h.transactions):
+		return History{}
+	}
+
+	similar := make(map[similarityKey][]time.Time, len(h.similar))
+	for key, times := range h.similar {
+		if i := searchTimes(times, from); i < len(times) {
+			similar[key] = times[i:]
+		}
+	}
+	return History{transactions: h.transactions[first:], similar: similar}
+}
+
+func indexSimilar(transactions []Transaction) map[similarityKey][]time.Time {
+	similar := map[similarityKey][]time.Time{}
+	for _, t := range transactions {
+		key := similarityKeyOf(t)
+		similar[key] = append(similar[key], t.Time)
+	}
+	return similar
+}
+
+func searchTransactions(transactions []Transaction, from time.Time) int {
+	return sort.Search(len(transactions), func(i int) bool {
+		return !transactions[i].Time.Before(from)
+	})
+}
+
+func searchTransactionsAfter(transactions []Transaction, to time.Time) int {
+	return sort.Search(len(transactions), func(i int) bool {
+		return transactions[i].Time.After(to)
+	})
+}
+
+func searchTimes(times []time.Time, from time.Time) int {
+	return sort.Search(len(times), func(i int) bool {
+		return !times[i].Before(from)
+	})
+}
+
+func searchTimesAfter(times []time.Time, to time.Time) int {
+	return sort.Search(len(times), func(i int) bool {
+		return times[i].After(to)
+	})
+}
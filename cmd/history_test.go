@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2020, 7, 12, 10, minute, 0, 0, time.UTC)
+	}
+	history := NewHistory(
+		Transaction{ID: "c", Merchant: "Alpha", Amount: 10, Time: at(3)},
+		Transaction{ID: "a", Merchant: "Alpha", Amount: 10, Time: at(0)},
+		Transaction{ID: "b", Merchant: "Beta", Amount: 20, Time: at(1)},
+	)
+
+	tests := map[string]func(*testing.T){
+		"Should keep transactions ordered by time": func(t *testing.T) {
+			// when
+			transactions := history.Transactions()
+
+			// then
+			assert.Equal(t, []string{"a", "b", "c"}, ids(transactions))
+		},
+		"Should return transactions between both times inclusive": func(t *testing.T) {
+			// when
+			between := history.Between(at(1), at(3))
+
+			// then
+			assert.Equal(t, []string{"b", "c"}, ids(between))
+		},
+		"Should count transactions and similar ones since the given time": func(t *testing.T) {
+			// when
+			matches := history.countSince(Transaction{Merchant: "Alpha", Amount: 10, Time: at(4)}, at(0))
+			recent := history.countSince(Transaction{Merchant: "Alpha", Amount: 10, Time: at(4)}, at(1))
+
+			// then
+			assert.Equal(t, 3, matches.frequency)
+			assert.Equal(t, 2, matches.similarity)
+			assert.Equal(t, 2, recent.frequency)
+			assert.Equal(t, 1, recent.similarity)
+		},
+		"Should add transaction in time order without changing the original history": func(t *testing.T) {
+			// when
+			with := history.with(Transaction{ID: "d", Merchant: "Beta", Amount: 20, Time: at(2)})
+
+			// then
+			assert.Equal(t, []string{"a", "b", "d", "c"}, ids(with.Transactions()))
+			assert.Equal(t, 2, with.countSince(Transaction{Merchant: "Beta", Amount: 20}, at(0)).similarity)
+			assert.Equal(t, 3, history.Len())
+			assert.Equal(t, 1, history.countSince(Transaction{Merchant: "Beta", Amount: 20}, at(0)).similarity)
+		},
+		"Should remove transaction by identifier": func(t *testing.T) {
+			// when
+			without := history.without("a")
+			unknown := history.without("z")
+
+			// then
+			assert.Equal(t, []string{"b", "c"}, ids(without.Transactions()))
+			assert.Equal(t, 1, without.countSince(Transaction{Merchant: "Alpha", Amount: 10}, at(0)).similarity)
+			assert.Equal(t, history, unknown)
+		},
+		"Should evict transactions older than the given time": func(t *testing.T) {
+			// when
+			recent := history.since(at(1))
+			empty := history.since(at(4))
+
+			// then
+			assert.Equal(t, []string{"b", "c"}, ids(recent.Transactions()))
+			assert.Equal(t, 1, recent.countSince(Transaction{Merchant: "Alpha", Amount: 10}, at(0)).similarity)
+			assert.Equal(t, 0, empty.Len())
+			assert.Equal(t, history, history.since(at(0)))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func ids(transactions []Transaction) []string {
+	var ids []string
+	for _, t := range transactions {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
@@ -1,21 +1,169 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
-func initHandler() Handler {
-	db := NewMemoryDB()
-	return Handler{
+// initHandler builds the handler from cfg. Events are only kept in memory when queryEvents is
+// set, as without a dataDir nothing else ever reads them.
+func initHandler(cfg Config, queryEvents bool) (Handler, error) {
+	rules := NewDefaultRuleRegistry(cfg)
+	if cfg.RulesFile != "" {
+		declarative, err := LoadDeclarativeRules(cfg.RulesFile)
+		if err != nil {
+			return Handler{}, err
+		}
+		if err := RegisterDeclarativeRules(rules, declarative); err != nil {
+			return Handler{}, err
+		}
+	}
+
+	rates := NewRateTable()
+	if cfg.RatesFile != "" {
+		loaded, err := LoadRateTable(cfg.RatesFile)
+		if err != nil {
+			return Handler{}, err
+		}
+		rates = loaded
+	}
+
+	categories := NewCategoryRegistry()
+	if cfg.MerchantsFile != "" {
+		loaded, err := LoadCategoryRegistry(cfg.MerchantsFile)
+		if err != nil {
+			return Handler{}, err
+		}
+		categories = loaded
+	}
+
+	db, events, err := openStorage(cfg, queryEvents)
+	if err != nil {
+		return Handler{}, err
+	}
+	if err := Rebuild(events, db); err != nil {
+		return Handler{}, err
+	}
+	manager := NewAccountManager(db).
+		WithRules(rules).
+		WithEvents(events).
+		WithRates(rates).
+		WithCategories(categories).
+		WithHoldExpiry(cfg.holdExpiry())
+	h := Handler{
 		db:             db,
-		accountHandler: NewAccountManager(db),
+		events:         events,
+		accountHandler: manager,
+	}
+	h.pool = NewPool(cfg.Workers, h.Dispatch)
+	return h, nil
+}
+
+func openStorage(cfg Config, queryEvents bool) (DB, EventStore, error) {
+	if cfg.DataDir == "" && queryEvents {
+		return NewMemoryDB(), NewMemoryEventStore(), nil
+	}
+	if cfg.DataDir == "" {
+		return NewMemoryDB(), NewTransientEventStore(), nil
+	}
+	db, err := OpenFileDB(cfg.DataDir)
+	if err != nil {
+		return nil, nil, err
 	}
+	events, err := OpenFileEventStore(cfg.DataDir)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, events, nil
 }
 
 func main() {
-	h := initHandler()
-	for {
-		fmt.Println(h.Encode(h.Dispatch(h.Decode(os.Stdin))))
+	cfg, args, err := LoadConfig(os.Args[1:], os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "effective configuration: %s\n", cfg)
+
+	h, err := initHandler(cfg, len(args) > 0 && args[0] == "serve")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	switch {
+	case len(args) > 0 && args[0] == "serve":
+		err = serve(h, args[1:])
+	case len(args) > 0 && args[0] == "grpc":
+		err = serveGRPC(h, args[1:])
+	default:
+		err = stream(h)
+	}
+	h.Close()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
+
+// stream stops reading stdin on SIGINT or SIGTERM, writing the decisions already taken before
+// returning.
+func stream(h Handler) error {
+	ctx, cancel := shutdownContext()
+	defer cancel()
+
+	return h.Stream(ctx, os.Stdin, os.Stdout)
+}
+
+func serve(h Handler, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address the HTTP server listens on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := shutdownContext()
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "listening on %s\n", *addr)
+	return NewServer(h).ListenAndServe(ctx, *addr)
+}
+
+func serveGRPC(h Handler, args []string) error {
+	flags := flag.NewFlagSet("grpc", flag.ContinueOnError)
+	addr := flags.String("addr", ":9090", "address the gRPC server listens on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := shutdownContext()
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "listening on %s\n", *addr)
+	return NewGRPCServer(h).Serve(ctx, listener)
+}
+
+func shutdownContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	return ctx, cancel
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,45 +20,390 @@ func TestIntegration(t *testing.T) {
 	tests := []contract{
 		{
 			`{ "account": { "activeCard": true, "availableLimit": 200 } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 200 }, "violations": [] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 200 }, "violations": [] }`,
 		},
 		{
 			`{ "account": { "activeCard": false, "availableLimit": 100 } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 200 }, "violations": ["account-already-initialized"] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 200 }, "violations": ["account-already-initialized"] }`,
 		},
 		{
 			`{ "transaction": { "merchant": "Alpha", "amount": 20, "time": "2020-07-12T10:29:59.000Z" } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 180 }, "violations": [] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 180 }, "violations": [] }`,
 		},
 		{
 			`{ "transaction": { "merchant": "Alpha", "amount": 40, "time": "2020-07-12T10:30:00.000Z" } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 140 }, "violations": [] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 140 }, "violations": [] }`,
 		},
 		{
 			`{ "transaction": { "merchant": "Beta", "amount": 40, "time": "2020-07-12T10:31:00.000Z" } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 100 }, "violations": [] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 100 }, "violations": [] }`,
 		},
 		{
 			`{ "transaction": { "merchant": "Omega", "amount": 100, "time": "2020-07-12T10:32:00.000Z" } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 0 }, "violations": [] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 0 }, "violations": [] }`,
 		},
 		{
 			`{ "transaction": { "merchant": "Omega", "amount": 100, "time": "2020-07-12T10:32:00.000Z" } }`,
-			`{ "account": { "activeCard": true, "availableLimit": 0 }, "violations": ["insufficient-limit", "high-frequency-small-interval", "doubled-transaction"] }`,
+			`{ "account": { "activeCard": true, "cardStatus": "active", "creditLimit": 200, "availableLimit": 0 }, "violations": ["insufficient-limit", "high-frequency-small-interval", "doubled-transaction"] }`,
+		},
+		{
+			`{ "account": { "accountId": 1, "activeCard": true, "availableLimit": 50 } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 50 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 1, "merchant": "Omega", "amount": 30, "time": "2020-07-12T10:32:30.000Z", "idempotencyKey": "retry" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 20 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 1, "merchant": "Omega", "amount": 30, "time": "2020-07-12T10:32:30.000Z", "idempotencyKey": "retry" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 20 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 1, "transactionId": "t1", "merchant": "Beta", "amount": 15, "time": "2020-07-12T10:32:40.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 5 }, "violations": [] }`,
+		},
+		{
+			`{ "refund": { "accountId": 1, "transactionId": "t1", "amount": 20, "time": "2020-07-12T10:32:50.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 5 }, "violations": ["refund-exceeds-original"] }`,
+		},
+		{
+			`{ "refund": { "accountId": 1, "transactionId": "t1", "amount": 5, "time": "2020-07-12T10:32:50.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 10 }, "violations": [] }`,
+		},
+		{
+			`{ "reversal": { "accountId": 1, "transactionId": "t1", "time": "2020-07-12T10:32:55.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 20 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 1, "merchant": "Beta", "amount": 15, "time": "2020-07-12T10:33:00.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 5 }, "violations": [] }`,
+		},
+		{
+			`{ "reversal": { "accountId": 1, "transactionId": "t1", "time": "2020-07-12T10:33:10.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 5 }, "violations": ["original-transaction-not-found"] }`,
+		},
+		{
+			`{ "accountUpdate": { "accountId": 1, "creditLimit": 40 } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 50, "availableLimit": 5 }, "violations": ["credit-limit-below-usage"] }`,
+		},
+		{
+			`{ "accountUpdate": { "accountId": 1, "creditLimit": 100 } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 55 }, "violations": [] }`,
+		},
+		{
+			`{ "card": { "accountId": 1, "action": "block", "reason": "lost card" } }`,
+			`{ "account": { "accountId": 1, "activeCard": false, "cardStatus": "blocked", "blockReason": "lost card", "creditLimit": 100, "availableLimit": 55 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 1, "merchant": "Gamma", "amount": 1, "time": "2020-07-12T10:40:00.000Z" } }`,
+			`{ "account": { "accountId": 1, "activeCard": false, "cardStatus": "blocked", "blockReason": "lost card", "creditLimit": 100, "availableLimit": 55 }, "violations": ["card-blocked"] }`,
+		},
+		{
+			`{ "card": { "accountId": 1, "action": "unblock" } }`,
+			`{ "account": { "accountId": 1, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 55 }, "violations": [] }`,
+		},
+		{
+			`{ "card": { "accountId": 1, "action": "close" } }`,
+			`{ "account": { "accountId": 1, "activeCard": false, "cardStatus": "closed", "creditLimit": 100, "availableLimit": 55 }, "violations": [] }`,
+		},
+		{
+			`{ "card": { "accountId": 1, "action": "activate" } }`,
+			`{ "account": { "accountId": 1, "activeCard": false, "cardStatus": "closed", "creditLimit": 100, "availableLimit": 55 }, "violations": ["card-closed"] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 2, "merchant": "Omega", "amount": 30, "time": "2020-07-12T10:33:00.000Z" } }`,
+			`{ "account": { "accountId": 2, "activeCard": false, "availableLimit": 0 }, "violations": ["account-not-initialized"] }`,
+		},
+		{
+			`{ "account": { "accountId": 3, "activeCard": true, "availableLimit": 500 } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 500 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 3, "transactionId": "h1", "merchant": "Grand Hotel", "amount": 300, "time": "2020-07-12T10:00:00.000Z", "hold": true } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 200 }, "violations": [] }`,
+		},
+		{
+			`{ "capture": { "accountId": 3, "transactionId": "h1", "amount": "345.50", "time": "2020-07-14T10:00:00.000Z" } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 154.50 }, "violations": [] }`,
+		},
+		{
+			`{ "capture": { "accountId": 3, "transactionId": "h1", "time": "2020-07-14T10:01:00.000Z" } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 154.50 }, "violations": ["hold-not-found"] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 3, "transactionId": "h2", "merchant": "Car Rental", "amount": 100, "time": "2020-07-15T10:00:00.000Z", "hold": true } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 54.50 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 3, "merchant": "Gas Station", "amount": 80, "time": "2020-07-22T10:00:00.000Z" } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 74.50 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 3, "merchant": "Gift Shop", "amount": 100, "time": "2020-07-22T11:00:00.000Z", "allowPartial": true } }`,
+			`{ "account": { "accountId": 3, "activeCard": true, "cardStatus": "active", "creditLimit": 500, "availableLimit": 0 }, "approval": { "status": "partially-approved", "requestedAmount": 100, "approvedAmount": 74.50 }, "violations": [] }`,
+		},
+		{
+			`{ "account": { "accountId": 4, "activeCard": true, "availableLimit": 1000, "spendingLimits": { "daily": 100 } } }`,
+			`{ "account": { "accountId": 4, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 1000, "spendingLimits": { "daily": 100 } }, "violations": [] }`,
+		},
+		{
+			`{ "spendingLimits": { "accountId": 4, "perTransaction": 80, "daily": 100, "timeZone": "America/Sao_Paulo" } }`,
+			`{ "account": { "accountId": 4, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 1000, "spendingLimits": { "perTransaction": 80, "daily": 100, "timeZone": "America/Sao_Paulo" } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 4, "merchant": "Bakery", "amount": 90, "time": "2020-07-12T10:00:00.000Z" } }`,
+			`{ "account": { "accountId": 4, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 1000, "spendingLimits": { "perTransaction": 80, "daily": 100, "timeZone": "America/Sao_Paulo" } }, "violations": ["transaction-limit-exceeded"] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 4, "merchant": "Bakery", "amount": 70, "time": "2020-07-12T10:00:00.000Z" } }`,
+			`{ "account": { "accountId": 4, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 930, "spendingLimits": { "perTransaction": 80, "daily": 100, "timeZone": "America/Sao_Paulo" } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 4, "merchant": "Pharmacy", "amount": 40, "time": "2020-07-13T02:00:00.000Z" } }`,
+			`{ "account": { "accountId": 4, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 930, "spendingLimits": { "perTransaction": 80, "daily": 100, "timeZone": "America/Sao_Paulo" } }, "violations": ["daily-limit-exceeded"] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 4, "merchant": "Pharmacy", "amount": 40, "time": "2020-07-13T03:00:00.000Z" } }`,
+			`{ "account": { "accountId": 4, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 890, "spendingLimits": { "perTransaction": 80, "daily": 100, "timeZone": "America/Sao_Paulo" } }, "violations": [] }`,
+		},
+		{
+			`{ "account": { "accountId": 5, "activeCard": true, "availableLimit": 1000, "velocityLimits": { "maxAmount": 500, "amountIntervalMinutes": 10 } } }`,
+			`{ "account": { "accountId": 5, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 1000, "velocityLimits": { "maxAmount": 500, "amountIntervalMinutes": 10 } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 5, "merchant": "Electronics", "amount": 450, "time": "2020-07-12T10:00:00.000Z" } }`,
+			`{ "account": { "accountId": 5, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 550, "velocityLimits": { "maxAmount": 500, "amountIntervalMinutes": 10 } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 5, "merchant": "Jewelry", "amount": 60, "time": "2020-07-12T10:05:00.000Z" } }`,
+			`{ "account": { "accountId": 5, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 550, "velocityLimits": { "maxAmount": 500, "amountIntervalMinutes": 10 } }, "violations": ["high-amount-small-interval"] }`,
+		},
+		{
+			`{ "velocityLimits": { "accountId": 5, "maxMerchants": 1, "merchantIntervalMinutes": 10 } }`,
+			`{ "account": { "accountId": 5, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 550, "velocityLimits": { "maxMerchants": 1, "merchantIntervalMinutes": 10 } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 5, "merchant": "Jewelry", "amount": 60, "time": "2020-07-12T10:06:00.000Z" } }`,
+			`{ "account": { "accountId": 5, "activeCard": true, "cardStatus": "active", "creditLimit": 1000, "availableLimit": 550, "velocityLimits": { "maxMerchants": 1, "merchantIntervalMinutes": 10 } }, "violations": ["many-merchants-small-interval"] }`,
+		},
+		{
+			`{ "account": { "accountId": 6, "activeCard": true, "availableLimit": 100 } }`,
+			`{ "account": { "accountId": 6, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100 }, "violations": [] }`,
+		},
+		{
+			`{ "merchants": { "accountId": 6, "action": "block", "pattern": "Lucky Casino*" } }`,
+			`{ "account": { "accountId": 6, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100, "merchants": { "blocked": ["Lucky Casino*"] } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 6, "merchant": "LUCKY-CASINO #42", "amount": 10, "time": "2020-07-12T10:00:00.000Z" } }`,
+			`{ "account": { "accountId": 6, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100, "merchants": { "blocked": ["Lucky Casino*"] } }, "violations": ["merchant-blocked"] }`,
+		},
+		{
+			`{ "merchants": { "accountId": 6, "action": "allow-listed-only" } }`,
+			`{ "account": { "accountId": 6, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100, "merchants": { "blocked": ["Lucky Casino*"], "allowedOnly": true } }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 6, "merchant": "Bakery", "amount": 10, "time": "2020-07-12T10:01:00.000Z" } }`,
+			`{ "account": { "accountId": 6, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100, "merchants": { "blocked": ["Lucky Casino*"], "allowedOnly": true } }, "violations": ["merchant-not-allowed"] }`,
+		},
+		{
+			`{ "merchants": { "accountId": 6, "action": "unblock", "pattern": "Bakery" } }`,
+			`{ "account": { "accountId": 6, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100, "merchants": { "blocked": ["Lucky Casino*"], "allowedOnly": true } }, "violations": ["merchant-not-listed"] }`,
+		},
+		{
+			`{ "account": { "accountId": 7, "activeCard": true, "availableLimit": 100 } }`,
+			`{ "account": { "accountId": 7, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 100 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 7, "merchant": "CITY TRANSIT 0042", "amount": 4, "time": "2020-07-12T08:00:00.000Z" } }`,
+			`{ "account": { "accountId": 7, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 96 }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 7, "merchant": "CITY TRANSIT 0042", "amount": 4, "time": "2020-07-12T08:00:20.000Z" } }`,
+			`{ "account": { "accountId": 7, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 96 }, "violations": ["doubled-transaction"] }`,
+		},
+		{
+			`{ "similarityExemptions": { "accountId": 7, "exemptions": [{ "merchant": "City Transit*", "maxSimilarityPerInterval": 2, "intervalMinutes": 1 }] } }`,
+			`{ "account": { "accountId": 7, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 96, "similarityExemptions": [{ "merchant": "City Transit*", "maxSimilarityPerInterval": 2, "intervalMinutes": 1 }] }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 7, "merchant": "CITY TRANSIT 0042", "amount": 4, "time": "2020-07-12T08:00:30.000Z" } }`,
+			`{ "account": { "accountId": 7, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 92, "similarityExemptions": [{ "merchant": "City Transit*", "maxSimilarityPerInterval": 2, "intervalMinutes": 1 }] }, "violations": [] }`,
+		},
+		{
+			`{ "transaction": { "accountId": 7, "merchant": "CITY TRANSIT 0042", "amount": 4, "time": "2020-07-12T08:00:40.000Z" } }`,
+			`{ "account": { "accountId": 7, "activeCard": true, "cardStatus": "active", "creditLimit": 100, "availableLimit": 92, "similarityExemptions": [{ "merchant": "City Transit*", "maxSimilarityPerInterval": 2, "intervalMinutes": 1 }] }, "violations": ["doubled-transaction"] }`,
 		},
 	}
 
 	// given
-	h := initHandler()
+	h := newTestHandler()
 
 	for _, contract := range tests {
 		// when
 		var stdin bytes.Buffer
 		stdin.Write([]byte(contract.input))
 
-		stdout := h.Encode(h.Dispatch(h.Decode(&stdin)))
+		req, err := h.Decode(&stdin)
+		stdout := h.Encode(h.Dispatch(req))
 
 		//then
+		assert.NoError(t, err)
 		assert.JSONEq(t, contract.output, stdout.String())
 	}
 }
+
+func TestInitHandler(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should evaluate declarative rules from configuration": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.RulesFile = writeRulesFile(t, `
+rules:
+  - name: big-spender
+    when: amount > 50
+    violation: big-spender
+`)
+			h, err := initHandler(cfg, true)
+			assert.NoError(t, err)
+			h.Dispatch(Account{CardStatus: CardActive, AvailableLimit: Units(100)})
+
+			// when
+			_, errs := h.Dispatch(Transaction{Merchant: "Acme Corporation", Amount: Units(60)})
+
+			// then
+			assert.Equal(t, []error{errors.New("big-spender")}, errs)
+		},
+		"Should convert foreign transactions with rates from configuration": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.RatesFile = writeRulesFile(t, `
+foreignTransactionFeePercent: 1.5
+rates:
+  - { from: USD, to: BRL, rate: 5, effectiveAt: 2020-07-01T00:00:00Z }
+`)
+			h, err := initHandler(cfg, true)
+			assert.NoError(t, err)
+			h.Dispatch(Account{CardStatus: CardActive, Currency: BRL, AvailableLimit: Units(100)})
+
+			// when
+			acc, errs := h.Dispatch(Transaction{Merchant: "Acme Corporation", Amount: Units(10), Currency: USD, Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(4925), acc.AvailableLimit)
+		},
+		"Should resolve categories with merchants from configuration": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.MerchantsFile = writeRulesFile(t, `
+categories:
+  - { name: cash-advance, codes: ["6010-6011"] }
+`)
+			h, err := initHandler(cfg, true)
+			assert.NoError(t, err)
+			h.Dispatch(Account{CardStatus: CardActive, AvailableLimit: Units(100)})
+			h.Dispatch(CategoryPolicyUpdate{CategoryPolicy: CategoryPolicy{Blocked: []string{"cash-advance"}}})
+
+			// when
+			_, errs := h.Dispatch(Transaction{Merchant: "ATM", MCC: "6011", Amount: Units(10), Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)})
+
+			// then
+			assert.Equal(t, []error{errors.New(CategoryBlocked)}, errs)
+		},
+		"Should not init handler with invalid merchants file": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.MerchantsFile = writeRulesFile(t, `categories: [{ name: travel, codes: ["travel"] }]`)
+
+			// when
+			_, err := initHandler(cfg, true)
+
+			// then
+			assert.Error(t, err)
+		},
+		"Should not init handler with invalid rates file": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.RatesFile = writeRulesFile(t, `rates: [{ from: USD, to: USD, rate: 1 }]`)
+
+			// when
+			_, err := initHandler(cfg, true)
+
+			// then
+			assert.EqualError(t, err, `rate #1: unsupported conversion from "USD" to "USD"`)
+		},
+		"Should keep accounts across restarts with a data directory": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.DataDir = tempDataDir(t)
+			h, err := initHandler(cfg, true)
+			assert.NoError(t, err)
+			h.Dispatch(Account{CardStatus: CardActive, AvailableLimit: 100})
+			h.Dispatch(Transaction{Merchant: "Acme Corporation", Amount: 60})
+			h.Close()
+
+			// when
+			h, err = initHandler(cfg, true)
+			assert.NoError(t, err)
+			acc, errs := h.Dispatch(Transaction{Merchant: "Acme Corporation", Amount: 60})
+
+			// then
+			assert.Equal(t, Money(40), acc.AvailableLimit)
+			assert.Equal(t, []error{errors.New(InsufficientLimit), errors.New(DoubledTransaction)}, errs)
+		},
+		"Should rebuild accounts from events when the projection is lost": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.DataDir = tempDataDir(t)
+			h, _ := initHandler(cfg, true)
+			h.Dispatch(Account{CardStatus: CardActive, AvailableLimit: 100})
+			h.Dispatch(Transaction{Merchant: "Acme Corporation", Amount: 60})
+			h.Close()
+			os.Remove(filepath.Join(cfg.DataDir, walFileName))
+
+			// when
+			h, err := initHandler(cfg, true)
+
+			// then
+			assert.NoError(t, err)
+			acc, _ := h.db.FindAccount(0)
+			assert.Equal(t, Money(40), acc.AvailableLimit)
+		},
+		"Should not keep events in memory when they are never queried": func(t *testing.T) {
+			// given
+			h, _ := initHandler(DefaultConfig(), false)
+
+			// when
+			h.Dispatch(Account{CardStatus: CardActive, AvailableLimit: 100})
+			acc, errs := h.Dispatch(Transaction{Merchant: "Acme Corporation", Amount: 60})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, Money(40), acc.AvailableLimit)
+			assert.Empty(t, h.events.Events())
+		},
+		"Should not initialize handler with invalid rules file": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.RulesFile = writeRulesFile(t, `rules: [{ name: broken, when: amount, violation: broken }]`)
+
+			// when
+			_, err := initHandler(cfg, true)
+
+			// then
+			assert.EqualError(t, err, "rule broken: expression must be a condition, got number")
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func newTestHandler() Handler {
+	h, _ := initHandler(DefaultConfig(), true)
+	return h
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+type MerchantAction string
+
+const (
+	BlockMerchant    MerchantAction = "block"
+	UnblockMerchant  MerchantAction = "unblock"
+	AllowMerchant    MerchantAction = "allow"
+	DisallowMerchant MerchantAction = "disallow"
+	AllowListedOnly  MerchantAction = "allow-listed-only"
+	AllowAnyMerchant MerchantAction = "allow-any"
+)
+
+type MerchantOperation struct {
+	AccountID int            `json:"accountId,omitempty"`
+	Action    MerchantAction `json:"action"`
+	Pattern   string         `json:"pattern,omitempty"`
+}
+
+func (op MerchantOperation) accountID() int {
+	return op.AccountID
+}
+
+// MerchantLists restricts the merchants an account can transact with. Patterns match merchant names
+// regardless of case, spacing and punctuation, and a trailing * matches any name starting with
+// the rest of the pattern. When AllowedOnly is set, only merchants matching Allowed are accepted.
+type MerchantLists struct {
+	Blocked     []string `json:"blocked,omitempty"`
+	Allowed     []string `json:"allowed,omitempty"`
+	AllowedOnly bool     `json:"allowedOnly,omitempty"`
+}
+
+// change returns a copy of the lists with the operation applied, or nil when nothing is
+// restricted anymore.
+func (l *MerchantLists) change(op MerchantOperation) (*MerchantLists, error) {
+	var changed MerchantLists
+	if l != nil {
+		changed = *l
+	}
+
+	var err error
+	switch op.Action {
+	case BlockMerchant:
+		changed.Blocked, err = withPattern(changed.Blocked, op.Pattern)
+	case UnblockMerchant:
+		changed.Blocked, err = withoutPattern(changed.Blocked, op.Pattern)
+	case AllowMerchant:
+		changed.Allowed, err = withPattern(changed.Allowed, op.Pattern)
+	case DisallowMerchant:
+		changed.Allowed, err = withoutPattern(changed.Allowed, op.Pattern)
+	case AllowListedOnly:
+		changed.AllowedOnly = true
+	case AllowAnyMerchant:
+		changed.AllowedOnly = false
+	default:
+		err = errors.New(InvalidMerchantAction)
+	}
+	if err != nil {
+		return l, err
+	}
+
+	if len(changed.Blocked) == 0 && len(changed.Allowed) == 0 && !changed.AllowedOnly {
+		return nil, nil
+	}
+	return &changed, nil
+}
+
+func (l MerchantLists) validate() error {
+	for _, pattern := range append(append([]string{}, l.Blocked...), l.Allowed...) {
+		if normalizePattern(pattern) == "" {
+			return errors.New(MerchantPatternRequired)
+		}
+	}
+	return nil
+}
+
+func (l *MerchantLists) blocks(merchant string) bool {
+	return l != nil && matchesAny(l.Blocked, merchant)
+}
+
+func (l *MerchantLists) allows(merchant string) bool {
+	return l == nil || !l.AllowedOnly || matchesAny(l.Allowed, merchant)
+}
+
+func withPattern(patterns []string, pattern string) ([]string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if normalizePattern(pattern) == "" {
+		return patterns, errors.New(MerchantPatternRequired)
+	}
+	if indexOfPattern(patterns, pattern) >= 0 {
+		return patterns, errors.New(MerchantAlreadyListed)
+	}
+	return append(append([]string{}, patterns...), pattern), nil
+}
+
+func withoutPattern(patterns []string, pattern string) ([]string, error) {
+	i := indexOfPattern(patterns, pattern)
+	if i < 0 {
+		return patterns, errors.New(MerchantNotListed)
+	}
+	remaining := make([]string, 0, len(patterns)-1)
+	remaining = append(remaining, patterns[:i]...)
+	return append(remaining, patterns[i+1:]...), nil
+}
+
+func indexOfPattern(patterns []string, pattern string) int {
+	for i, p := range patterns {
+		if normalizePattern(p) == normalizePattern(pattern) {
+			return i
+		}
+	}
+	return -1
+}
+
+func matchesAny(patterns []string, merchant string) bool {
+	name := normalizeMerchant(merchant)
+	for _, pattern := range patterns {
+		if matchesPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPattern(pattern string, name string) bool {
+	normalized := normalizePattern(pattern)
+	if strings.HasSuffix(normalized, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(normalized, "*"))
+	}
+	return name == normalized
+}
+
+// normalizePattern normalizes the pattern like a merchant name, keeping the trailing * of prefixes.
+func normalizePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if strings.HasSuffix(pattern, "*") {
+		prefix := normalizeMerchant(strings.TrimSuffix(pattern, "*"))
+		if prefix == "" {
+			return ""
+		}
+		return prefix + "*"
+	}
+	return normalizeMerchant(pattern)
+}
+
+// normalizeMerchant folds the case of a merchant name and turns every run of spaces and
+// punctuation into a single space, so "ACME-Corp." and "acme corp" are the same merchant.
+func normalizeMerchant(merchant string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(merchant), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerchantLists(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should match merchant names regardless of case and punctuation": func(t *testing.T) {
+			// given
+			lists := &MerchantLists{Blocked: []string{"Acme Corp"}}
+
+			// then
+			assert.True(t, lists.blocks("ACME-CORP."))
+			assert.True(t, lists.blocks("  acme   corp "))
+			assert.False(t, lists.blocks("Acme Corporation"))
+			assert.False(t, (*MerchantLists)(nil).blocks("Acme Corp"))
+		},
+		"Should match merchant names starting with prefix patterns": func(t *testing.T) {
+			// given
+			lists := &MerchantLists{Blocked: []string{"bet*", "Streaming Co *"}}
+
+			// then
+			assert.True(t, lists.blocks("BET365 LTD"))
+			assert.True(t, lists.blocks("Betting House"))
+			assert.True(t, lists.blocks("STREAMING CO*MONTHLY"))
+			assert.False(t, lists.blocks("Alphabet"))
+		},
+		"Should only allow listed merchants when allowed only": func(t *testing.T) {
+			// given
+			listed := &MerchantLists{Allowed: []string{"Transit*"}}
+			allowedOnly := &MerchantLists{Allowed: []string{"Transit*"}, AllowedOnly: true}
+
+			// then
+			assert.True(t, listed.allows("Grocery"))
+			assert.True(t, allowedOnly.allows("TRANSIT AUTHORITY"))
+			assert.False(t, allowedOnly.allows("Grocery"))
+			assert.True(t, (*MerchantLists)(nil).allows("Grocery"))
+		},
+		"Should block and allow patterns without changing the original lists": func(t *testing.T) {
+			// given
+			original := &MerchantLists{Blocked: []string{"bet*"}}
+
+			// when
+			blocked, errBlock := original.change(MerchantOperation{Action: BlockMerchant, Pattern: " Casino "})
+			allowed, errAllow := blocked.change(MerchantOperation{Action: AllowMerchant, Pattern: "Grocery"})
+			restricted, errRestrict := allowed.change(MerchantOperation{Action: AllowListedOnly})
+
+			// then
+			assert.NoError(t, errBlock)
+			assert.NoError(t, errAllow)
+			assert.NoError(t, errRestrict)
+			assert.Equal(t, &MerchantLists{Blocked: []string{"bet*"}}, original)
+			assert.Equal(t, &MerchantLists{Blocked: []string{"bet*", "Casino"}, Allowed: []string{"Grocery"}, AllowedOnly: true}, restricted)
+		},
+		"Should remove the lists when nothing is restricted anymore": func(t *testing.T) {
+			// given
+			lists := &MerchantLists{Blocked: []string{"bet*"}}
+
+			// when
+			unblocked, err := lists.change(MerchantOperation{Action: UnblockMerchant, Pattern: "BET *"})
+
+			// then
+			assert.NoError(t, err)
+			assert.Nil(t, unblocked)
+		},
+		"Should reject missing, repeated and unknown patterns": func(t *testing.T) {
+			// given
+			lists := &MerchantLists{Blocked: []string{"bet*"}}
+
+			// when
+			_, missing := lists.change(MerchantOperation{Action: BlockMerchant, Pattern: " * "})
+			_, repeated := lists.change(MerchantOperation{Action: BlockMerchant, Pattern: "Bet*"})
+			_, unknown := lists.change(MerchantOperation{Action: DisallowMerchant, Pattern: "bet*"})
+			_, invalid := lists.change(MerchantOperation{Action: MerchantAction("mute")})
+
+			// then
+			assert.Equal(t, errors.New(MerchantPatternRequired), missing)
+			assert.Equal(t, errors.New(MerchantAlreadyListed), repeated)
+			assert.Equal(t, errors.New(MerchantNotListed), unknown)
+			assert.Equal(t, errors.New(InvalidMerchantAction), invalid)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units, such as cents. Every supported currency has two decimal
+// places, so the same amount means the same value whatever the currency it is informed in.
+type Money int64
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	BRL Currency = "BRL"
+	USD Currency = "USD"
+
+	// DefaultCurrency is assumed for accounts and transactions that inform no currency.
+	DefaultCurrency = BRL
+
+	minorUnits    = 100
+	decimalPlaces = 2
+)
+
+var ErrAmountOverflow = errors.New(AmountOverflow)
+
+// Units returns the money worth the given whole units of a currency.
+func Units(units int64) Money {
+	return Money(units * minorUnits)
+}
+
+// ParseMoney reads a decimal amount, such as 99.90, with up to two decimal places.
+func ParseMoney(s string) (Money, error) {
+	units, err := parseDecimal(s, decimalPlaces)
+	if err != nil {
+		return 0, fmt.Errorf("amount %w", err)
+	}
+	return Money(units), nil
+}
+
+// parseDecimal reads a decimal number as an integer scaled by the given decimal places,
+// rejecting anything that would lose precision or overflow.
+func parseDecimal(s string, places int) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction := strings.TrimPrefix(s, "-"), ""
+	if i := strings.IndexByte(whole, '.'); i >= 0 {
+		whole, fraction = whole[:i], whole[i+1:]
+		if fraction == "" {
+			return 0, fmt.Errorf("%q is not a decimal number", s)
+		}
+	}
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%q is not a decimal number", s)
+	}
+	if len(fraction) > places {
+		if strings.Trim(fraction[places:], "0") != "" {
+			return 0, fmt.Errorf("%q has more than %d decimal places", s, places)
+		}
+		fraction = fraction[:places]
+	}
+	fraction += strings.Repeat("0", places-len(fraction))
+
+	value, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is out of range", s)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) String() string {
+	units := int64(m)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	whole, cents := units/minorUnits, units%minorUnits
+	if whole < 0 {
+		whole = -whole
+	}
+	if cents < 0 {
+		cents = -cents
+	}
+	if cents == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, whole, cents)
+}
+
+// Float returns the amount in whole units, as used by declarative rules.
+func (m Money) Float() float64 {
+	return float64(m) / minorUnits
+}
+
+// Add sums both amounts, failing with ErrAmountOverflow instead of wrapping around.
+func (m Money) Add(other Money) (Money, error) {
+	if (other > 0 && m > math.MaxInt64-other) || (other < 0 && m < math.MinInt64-other) {
+		return 0, ErrAmountOverflow
+	}
+	return m + other, nil
+}
+
+// Sub subtracts other from the amount, failing with ErrAmountOverflow instead of wrapping around.
+func (m Money) Sub(other Money) (Money, error) {
+	if other == math.MinInt64 {
+		return 0, ErrAmountOverflow
+	}
+	return m.Add(-other)
+}
+
+// MarshalJSON writes the amount as a decimal number, leaving out the decimal places of whole amounts.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts amounts as numbers or decimal strings, such as 20, 99.9 or "99.90".
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (c Currency) isValid() bool {
+	switch c {
+	case BRL, USD:
+		return true
+	}
+	return false
+}
+
+// orDefault returns the currency, or DefaultCurrency when none is informed.
+func (c Currency) orDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+func (c *Currency) UnmarshalJSON(data []byte) error {
+	var code string
+	if err := json.Unmarshal(data, &code); err != nil {
+		return err
+	}
+	currency, err := ParseCurrency(code)
+	if err != nil {
+		return err
+	}
+	*c = currency
+	return nil
+}
+
+// ParseCurrency accepts the supported currency codes regardless of case, or none at all.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(code))
+	if currency != "" && !currency.isValid() {
+		return "", fmt.Errorf("unsupported currency %q", code)
+	}
+	return currency, nil
+}
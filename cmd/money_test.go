@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should parse decimal amounts into minor units": func(t *testing.T) {
+			cases := map[string]Money{
+				"20":      2000,
+				"99.9":    9990,
+				"99.90":   9990,
+				"0.05":    5,
+				"-12.34":  -1234,
+				"1.500":   150,
+				"0":       0,
+				"1000000": 100000000,
+			}
+			for source, expected := range cases {
+				m, err := ParseMoney(source)
+				assert.NoError(t, err, source)
+				assert.Equal(t, expected, m, source)
+			}
+		},
+		"Should not parse malformed amounts": func(t *testing.T) {
+			for _, source := range []string{"", "-", ".5", "5.", "1,00", "1e3", "abc", "1.2.3", "0.001"} {
+				_, err := ParseMoney(source)
+				assert.Error(t, err, source)
+			}
+		},
+		"Should not parse amounts out of range": func(t *testing.T) {
+			// when
+			_, err := ParseMoney("92233720368547758.08")
+
+			// then
+			assert.EqualError(t, err, `amount "92233720368547758.08" is out of range`)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should add and subtract amounts": func(t *testing.T) {
+			// when
+			sum, errSum := Money(150).Add(50)
+			difference, errDifference := Money(150).Sub(200)
+
+			// then
+			assert.NoError(t, errSum)
+			assert.Equal(t, Money(200), sum)
+			assert.NoError(t, errDifference)
+			assert.Equal(t, Money(-50), difference)
+		},
+		"Should fail instead of overflowing": func(t *testing.T) {
+			// when
+			_, errAdd := Money(math.MaxInt64).Add(1)
+			_, errSub := Money(math.MinInt64).Sub(1)
+			_, errNegate := Money(0).Sub(math.MinInt64)
+
+			// then
+			assert.Equal(t, ErrAmountOverflow, errAdd)
+			assert.Equal(t, ErrAmountOverflow, errSub)
+			assert.Equal(t, ErrAmountOverflow, errNegate)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should decode amounts from numbers and decimal strings": func(t *testing.T) {
+			// given
+			var tr Transaction
+
+			// when
+			errNumber := json.Unmarshal([]byte(`{ "amount": 99.9, "currency": "usd" }`), &tr)
+			number := tr
+			errString := json.Unmarshal([]byte(`{ "amount": "99.90" }`), &tr)
+
+			// then
+			assert.NoError(t, errNumber)
+			assert.Equal(t, Money(9990), number.Amount)
+			assert.Equal(t, USD, number.Currency)
+			assert.NoError(t, errString)
+			assert.Equal(t, Money(9990), tr.Amount)
+		},
+		"Should encode amounts as decimal numbers": func(t *testing.T) {
+			// when
+			content, err := json.Marshal([]Money{2000, 9990, 5, -1234})
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, `[20,99.90,0.05,-12.34]`, string(content))
+		},
+		"Should not decode unsupported currency": func(t *testing.T) {
+			// given
+			var tr Transaction
+
+			// when
+			err := json.Unmarshal([]byte(`{ "amount": 10, "currency": "XYZ" }`), &tr)
+
+			// then
+			assert.EqualError(t, err, `unsupported currency "XYZ"`)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sync"
+)
+
+const poolQueueSize = 64
+
+// Pool processes requests on a fixed number of workers. Every request of an account is
+// handled by the same worker, so requests of different accounts run in parallel while
+// those of the same account are processed one at a time, in the order they were submitted.
+type Pool struct {
+	queues []chan task
+	done   sync.WaitGroup
+}
+
+type task struct {
+	request  accountRequest
+	decision chan Decision
+}
+
+func NewPool(workers int, dispatch func(accountRequest) (Account, []error)) *Pool {
+	p := &Pool{queues: make([]chan task, workers)}
+	for i := range p.queues {
+		p.queues[i] = make(chan task, poolQueueSize)
+		p.done.Add(1)
+		go p.work(p.queues[i], dispatch)
+	}
+	return p
+}
+
+// Submit queues the request on the worker owning its account, returning a channel
+// that receives the decision once it has been processed.
+func (p *Pool) Submit(request accountRequest) <-chan Decision {
+	t := task{request: request, decision: make(chan Decision, 1)}
+	worker := uint(request.accountID()) % uint(len(p.queues))
+	p.queues[worker] <- t
+	return t.decision
+}
+
+// Close waits for every submitted request to be processed before stopping the workers.
+func (p *Pool) Close() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.done.Wait()
+}
+
+func (p *Pool) work(queue <-chan task, dispatch func(accountRequest) (Account, []error)) {
+	defer p.done.Done()
+	for t := range queue {
+		acc, errs := dispatch(t.request)
+		t.decision <- Decision{Account: acc, Errors: errs}
+	}
+}
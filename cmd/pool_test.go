@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should process requests of the same account in submission order": func(t *testing.T) {
+			// given
+			var mutex sync.Mutex
+			processed := map[int][]Money{}
+			p := NewPool(4, func(request accountRequest) (Account, []error) {
+				tr := request.(Transaction)
+				mutex.Lock()
+				processed[tr.AccountID] = append(processed[tr.AccountID], tr.Amount)
+				mutex.Unlock()
+				return Account{ID: tr.AccountID, AvailableLimit: tr.Amount}, nil
+			})
+
+			// when
+			var decisions []<-chan Decision
+			for amount := Money(0); amount < 100; amount++ {
+				for id := 1; id <= 3; id++ {
+					decisions = append(decisions, p.Submit(Transaction{AccountID: id, Amount: amount}))
+				}
+			}
+			p.Close()
+
+			// then
+			for i, decision := range decisions {
+				assert.Equal(t, Account{ID: i%3 + 1, AvailableLimit: Money(i / 3)}, (<-decision).Account)
+			}
+			for id := 1; id <= 3; id++ {
+				assert.Len(t, processed[id], 100)
+				for amount, processedAmount := range processed[id] {
+					assert.Equal(t, Money(amount), processedAmount)
+				}
+			}
+		},
+		"Should route requests by account": func(t *testing.T) {
+			for _, request := range []accountRequest{
+				Account{ID: 7},
+				Transaction{AccountID: 7},
+				Refund{AccountID: 7},
+				Reversal{AccountID: 7},
+				Capture{AccountID: 7},
+				CardOperation{AccountID: 7},
+				AccountUpdate{AccountID: 7},
+				SpendingLimitsUpdate{AccountID: 7},
+				VelocityLimitsUpdate{AccountID: 7},
+				MerchantOperation{AccountID: 7},
+				CategoryPolicyUpdate{AccountID: 7},
+				SimilarityExemptionsUpdate{AccountID: 7},
+			} {
+				assert.Equal(t, 7, request.accountID())
+			}
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExchangeRate is a decimal multiplier kept in millionths, so 5.4321 is 5432100.
+type ExchangeRate int64
+
+const ratePlaces = 6
+
+func ParseExchangeRate(s string) (ExchangeRate, error) {
+	rate, err := parseDecimal(s, ratePlaces)
+	if err != nil {
+		return 0, fmt.Errorf("rate %w", err)
+	}
+	return ExchangeRate(rate), nil
+}
+
+func (r ExchangeRate) String() string {
+	scale := int64(1)
+	for i := 0; i < ratePlaces; i++ {
+		scale *= 10
+	}
+	whole, fraction := int64(r)/scale, int64(r)%scale
+	if fraction == 0 {
+		return fmt.Sprintf("%d", whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%0*d", whole, ratePlaces, fraction), "0")
+}
+
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *ExchangeRate) UnmarshalYAML(value *yaml.Node) error {
+	rate, err := ParseExchangeRate(value.Value)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// times multiplies the amount by the rate, rounding half away from zero to the nearest minor unit.
+func (m Money) times(r ExchangeRate) (Money, error) {
+	scale := big.NewInt(1)
+	scale.Exp(big.NewInt(10), big.NewInt(ratePlaces), nil)
+
+	product := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(r)))
+	quotient, remainder := new(big.Int).QuoRem(product, scale, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(scale) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+	if !quotient.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+	return Money(quotient.Int64()), nil
+}
+
+// Conversion describes how a transaction informed in a foreign currency was charged in the
+// account currency: the converted amount plus the foreign transaction fee.
+type Conversion struct {
+	OriginalAmount   Money        `json:"originalAmount"`
+	OriginalCurrency Currency     `json:"originalCurrency"`
+	Rate             ExchangeRate `json:"rate"`
+	ConvertedAmount  Money        `json:"convertedAmount"`
+	Fee              Money        `json:"fee,omitempty"`
+	Currency         Currency     `json:"currency"`
+}
+
+// Charged is the amount debited from the account.
+func (c Conversion) Charged() (Money, error) {
+	return c.ConvertedAmount.Add(c.Fee)
+}
+
+// scaledTo describes the part of the conversion that charges only the given amount, keeping the
+// rate and splitting the amount between the converted amount and the fee in the same proportion.
+func (c Conversion) scaledTo(charged Money) Conversion {
+	total, err := c.Charged()
+	if err != nil || total <= 0 || charged == total {
+		return c
+	}
+	scaled := c
+	scaled.OriginalAmount = c.OriginalAmount.proportion(charged, total)
+	scaled.ConvertedAmount = c.ConvertedAmount.proportion(charged, total)
+	scaled.Fee = charged - scaled.ConvertedAmount
+	return scaled
+}
+
+// proportion multiplies the amount by part/whole, rounding half away from zero to the nearest
+// minor unit. Part is expected to be at most whole, so the result never overflows.
+func (m Money) proportion(part Money, whole Money) Money {
+	product := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(part)))
+	divisor := big.NewInt(int64(whole))
+	quotient, remainder := new(big.Int).QuoRem(product, divisor, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+	return Money(quotient.Int64())
+}
+
+// RateTable keeps the exchange rates between currency pairs along with the time each one
+// becomes effective, so transactions are converted with the rate in effect when they happened.
+type RateTable struct {
+	rates map[currencyPair][]effectiveRate
+	fee   ExchangeRate
+}
+
+type currencyPair struct {
+	from Currency
+	to   Currency
+}
+
+type effectiveRate struct {
+	rate        ExchangeRate
+	effectiveAt time.Time
+}
+
+func NewRateTable() *RateTable {
+	return &RateTable{rates: map[currencyPair][]effectiveRate{}}
+}
+
+// WithFee returns the table charging the given fraction of every converted amount, such as 0.04,
+// as a foreign transaction fee.
+func (t *RateTable) WithFee(fee ExchangeRate) *RateTable {
+	return &RateTable{rates: t.rates, fee: fee}
+}
+
+// Add registers the rate converting from one currency to another since the given time.
+func (t *RateTable) Add(from Currency, to Currency, rate ExchangeRate, effectiveAt time.Time) {
+	pair := currencyPair{from, to}
+	rates := append(t.rates[pair], effectiveRate{rate, effectiveAt})
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].effectiveAt.Before(rates[j].effectiveAt)
+	})
+	t.rates[pair] = rates
+}
+
+// Convert charges the transaction amount in the given currency using the latest rate effective at
+// the transaction time, reporting false when there is no such rate.
+func (t *RateTable) Convert(tr Transaction, to Currency) (Conversion, bool, error) {
+	rates := t.rates[currencyPair{tr.Currency.orDefault(), to}]
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].effectiveAt.After(tr.Time)
+	})
+	if i == 0 {
+		return Conversion{}, false, nil
+	}
+
+	rate := rates[i-1].rate
+	converted, err := tr.Amount.times(rate)
+	if err != nil {
+		return Conversion{}, true, err
+	}
+	fee, err := converted.times(t.fee)
+	if err != nil {
+		return Conversion{}, true, err
+	}
+	conversion := Conversion{
+		OriginalAmount:   tr.Amount,
+		OriginalCurrency: tr.Currency.orDefault(),
+		Rate:             rate,
+		ConvertedAmount:  converted,
+		Fee:              fee,
+		Currency:         to,
+	}
+	if _, err := conversion.Charged(); err != nil {
+		return Conversion{}, true, err
+	}
+	return conversion, true, nil
+}
+
+func LoadRateTable(path string) (*RateTable, error) {
+	type definition struct {
+		From        string       `yaml:"from"`
+		To          string       `yaml:"to"`
+		Rate        ExchangeRate `yaml:"rate"`
+		EffectiveAt time.Time    `yaml:"effectiveAt"`
+	}
+	type document struct {
+		ForeignTransactionFeePercent string       `yaml:"foreignTransactionFeePercent"`
+		Rates                        []definition `yaml:"rates"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var doc document
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid rates file %s: %w", path, err)
+	}
+	var fee int64
+	if doc.ForeignTransactionFeePercent != "" {
+		// a percentage with two decimal places less is the same fraction in millionths
+		fee, err = parseDecimal(doc.ForeignTransactionFeePercent, ratePlaces-2)
+		if err != nil || fee < 0 {
+			return nil, fmt.Errorf("foreignTransactionFeePercent must be a positive percentage, got %q", doc.ForeignTransactionFeePercent)
+		}
+	}
+
+	table := NewRateTable()
+	for i, def := range doc.Rates {
+		from, to := Currency(strings.ToUpper(def.From)), Currency(strings.ToUpper(def.To))
+		if !from.isValid() || !to.isValid() || from == to {
+			return nil, fmt.Errorf("rate #%d: unsupported conversion from %q to %q", i+1, def.From, def.To)
+		}
+		if def.Rate <= 0 {
+			return nil, fmt.Errorf("rate #%d: rate must be greater than zero", i+1)
+		}
+		table.Add(from, to, def.Rate, def.EffectiveAt)
+	}
+	return table.WithFee(ExchangeRate(fee)), nil
+}
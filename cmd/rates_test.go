@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRateTable(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should load rates and foreign transaction fee": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `
+foreignTransactionFeePercent: 4
+rates:
+  - from: usd
+    to: BRL
+    rate: 5.4321
+    effectiveAt: 2020-07-12T00:00:00Z
+`)
+
+			// when
+			table, err := LoadRateTable(path)
+
+			// then
+			assert.NoError(t, err)
+			conversion, found, err := table.Convert(Transaction{Amount: Units(10), Currency: USD, Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)}, BRL)
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, Conversion{
+				OriginalAmount:   Units(10),
+				OriginalCurrency: USD,
+				Rate:             5432100,
+				ConvertedAmount:  5432,
+				Fee:              217,
+				Currency:         BRL,
+			}, conversion)
+		},
+		"Should not load rates between unsupported currencies": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `rates: [{ from: USD, to: XYZ, rate: 1, effectiveAt: 2020-07-12T00:00:00Z }]`)
+
+			// when
+			_, err := LoadRateTable(path)
+
+			// then
+			assert.EqualError(t, err, `rate #1: unsupported conversion from "USD" to "XYZ"`)
+		},
+		"Should not load rates that are not positive decimals": func(t *testing.T) {
+			// given
+			zero := writeRulesFile(t, `rates: [{ from: USD, to: BRL, rate: 0, effectiveAt: 2020-07-12T00:00:00Z }]`)
+			malformed := writeRulesFile(t, `rates: [{ from: USD, to: BRL, rate: 5.1234567, effectiveAt: 2020-07-12T00:00:00Z }]`)
+
+			// when
+			_, errZero := LoadRateTable(zero)
+			_, errMalformed := LoadRateTable(malformed)
+
+			// then
+			assert.EqualError(t, errZero, "rate #1: rate must be greater than zero")
+			assert.Error(t, errMalformed)
+		},
+		"Should not load negative fee": func(t *testing.T) {
+			// given
+			path := writeRulesFile(t, `foreignTransactionFeePercent: -1`)
+
+			// when
+			_, err := LoadRateTable(path)
+
+			// then
+			assert.EqualError(t, err, `foreignTransactionFeePercent must be a positive percentage, got "-1"`)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	table := NewRateTable()
+	table.Add(USD, BRL, 5000000, time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC))
+	table.Add(USD, BRL, 5500000, time.Date(2020, 7, 12, 0, 0, 0, 0, time.UTC))
+
+	tests := map[string]func(*testing.T){
+		"Should convert with the rate effective at the transaction time": func(t *testing.T) {
+			// when
+			before, _, _ := table.Convert(Transaction{Amount: Units(10), Currency: USD, Time: time.Date(2020, 7, 11, 23, 59, 0, 0, time.UTC)}, BRL)
+			after, _, _ := table.Convert(Transaction{Amount: Units(10), Currency: USD, Time: time.Date(2020, 7, 12, 0, 0, 0, 0, time.UTC)}, BRL)
+
+			// then
+			assert.Equal(t, Units(50), before.ConvertedAmount)
+			assert.Equal(t, Units(55), after.ConvertedAmount)
+		},
+		"Should not convert before the first rate is effective or without rate": func(t *testing.T) {
+			// when
+			_, early, _ := table.Convert(Transaction{Amount: Units(10), Currency: USD, Time: time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC)}, BRL)
+			_, inverse, _ := table.Convert(Transaction{Amount: Units(10), Currency: BRL, Time: time.Date(2020, 7, 12, 0, 0, 0, 0, time.UTC)}, USD)
+
+			// then
+			assert.False(t, early)
+			assert.False(t, inverse)
+		},
+		"Should round converted amounts and fees half away from zero": func(t *testing.T) {
+			// given
+			withFee := table.WithFee(15000)
+
+			// when
+			conversion, _, _ := withFee.Convert(Transaction{Amount: 33, Currency: USD, Time: time.Date(2020, 7, 12, 0, 0, 0, 0, time.UTC)}, BRL)
+			charged, _ := conversion.Charged()
+
+			// then
+			assert.Equal(t, Money(182), conversion.ConvertedAmount)
+			assert.Equal(t, Money(3), conversion.Fee)
+			assert.Equal(t, Money(185), charged)
+		},
+		"Should fail instead of overflowing": func(t *testing.T) {
+			// when
+			_, found, err := table.Convert(Transaction{Amount: Money(1 << 62), Currency: USD, Time: time.Date(2020, 7, 12, 0, 0, 0, 0, time.UTC)}, BRL)
+
+			// then
+			assert.True(t, found)
+			assert.Equal(t, ErrAmountOverflow, err)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
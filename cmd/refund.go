@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+)
+
+type Refund struct {
+	AccountID     int       `json:"accountId,omitempty"`
+	TransactionID string    `json:"transactionId"`
+	Amount        Money     `json:"amount,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+func (rf Refund) accountID() int {
+	return rf.AccountID
+}
+
+type Reversal struct {
+	AccountID     int       `json:"accountId,omitempty"`
+	TransactionID string    `json:"transactionId"`
+	Time          time.Time `json:"time"`
+}
+
+func (rv Reversal) accountID() int {
+	return rv.AccountID
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// RefundRetention is how long an authorized transaction stays refundable, capturable and
+// reversible, and rejects later transactions reusing its identifier.
+const RefundRetention = 180 * 24 * time.Hour
+
+const refundableShards = 64
+
+// Refundable keeps the authorized transactions informing an identifier, indexed by it, until they
+// are fully refunded or reversed, even after they leave the history used by the velocity rules.
+// Like History it is never changed in place. Transactions are spread over shards by identifier so
+// a change only copies the shard it touches, and open holds are indexed apart so expiring them does
+// not go through every transaction. Captured transactions older than RefundRetention are dropped
+// from a shard whenever a newer transaction is kept in it.
+type Refundable struct {
+	shards *[refundableShards]map[string]Transaction
+	holds  map[string]struct{}
+	len    int
+}
+
+// NewRefundable keeps every transaction informed, regardless of its age.
+func NewRefundable(transactions ...Transaction) Refundable {
+	r := Refundable{}
+	for _, tr := range transactions {
+		r = r.set(tr, time.Time{})
+	}
+	return r
+}
+
+func (r Refundable) Len() int {
+	return r.len
+}
+
+func (r Refundable) find(id string) (Transaction, bool) {
+	if id == "" || r.shards == nil {
+		return Transaction{}, false
+	}
+	tr, found := r.shards[shardOf(id)][id]
+	return tr, found
+}
+
+// Transactions returns every transaction kept, ordered by time and then by identifier.
+func (r Refundable) Transactions() []Transaction {
+	var transactions []Transaction
+	if r.shards != nil {
+		for _, shard := range r.shards {
+			for _, tr := range shard {
+				transactions = append(transactions, tr)
+			}
+		}
+	}
+	sortTransactions(transactions)
+	return transactions
+}
+
+// Holds returns the holds not captured yet, ordered by time and then by identifier.
+func (r Refundable) Holds() []Transaction {
+	transactions := make([]Transaction, 0, len(r.holds))
+	for id := range r.holds {
+		tr, _ := r.find(id)
+		transactions = append(transactions, tr)
+	}
+	sortTransactions(transactions)
+	return transactions
+}
+
+// with returns a copy keeping tr as well, replacing the transaction with the same identifier.
+func (r Refundable) with(tr Transaction) Refundable {
+	return r.set(tr, tr.Time.Add(-RefundRetention))
+}
+
+// without returns a copy no longer keeping the transaction with the given identifier.
+func (r Refundable) without(id string) Refundable {
+	if _, found := r.find(id); !found {
+		return r
+	}
+	i := shardOf(id)
+	shard := make(map[string]Transaction, len(r.shards[i])-1)
+	for key, tr := range r.shards[i] {
+		if key != id {
+			shard[key] = tr
+		}
+	}
+	return r.withShard(i, shard, r.holdsWithout(id))
+}
+
+// set keeps tr in a copy of its shard, dropping the captured transactions of that shard authorized
+// before expired.
+func (r Refundable) set(tr Transaction, expired time.Time) Refundable {
+	i := shardOf(tr.ID)
+	var current map[string]Transaction
+	if r.shards != nil {
+		current = r.shards[i]
+	}
+
+	holds := r.holds
+	shard := make(map[string]Transaction, len(current)+1)
+	for key, kept := range current {
+		if !kept.Hold && kept.Time.Before(expired) {
+			continue
+		}
+		shard[key] = kept
+	}
+	shard[tr.ID] = tr
+
+	if _, held := holds[tr.ID]; held != tr.Hold {
+		holds = r.holdsWithout(tr.ID)
+		if tr.Hold {
+			holds = make(map[string]struct{}, len(r.holds)+1)
+			for id := range r.holds {
+				holds[id] = struct{}{}
+			}
+			holds[tr.ID] = struct{}{}
+		}
+	}
+	return r.withShard(i, shard, holds)
+}
+
+func (r Refundable) withShard(i int, shard map[string]Transaction, holds map[string]struct{}) Refundable {
+	shards := &[refundableShards]map[string]Transaction{}
+	if r.shards != nil {
+		*shards = *r.shards
+	}
+	size := r.len - len(shards[i]) + len(shard)
+	if size == 0 {
+		return Refundable{}
+	}
+	if len(shard) == 0 {
+		shard = nil
+	}
+	shards[i] = shard
+	return Refundable{shards: shards, holds: holds, len: size}
+}
+
+func (r Refundable) holdsWithout(id string) map[string]struct{} {
+	if _, held := r.holds[id]; !held {
+		return r.holds
+	}
+	if len(r.holds) == 1 {
+		return nil
+	}
+	holds := make(map[string]struct{}, len(r.holds)-1)
+	for key := range r.holds {
+		if key != id {
+			holds[key] = struct{}{}
+		}
+	}
+	return holds
+}
+
+func shardOf(id string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(id))
+	return int(hash.Sum32() % refundableShards)
+}
+
+func sortTransactions(transactions []Transaction) {
+	sort.Slice(transactions, func(i, j int) bool {
+		if !transactions[i].Time.Equal(transactions[j].Time) {
+			return transactions[i].Time.Before(transactions[j].Time)
+		}
+		return transactions[i].ID < transactions[j].ID
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundable(t *testing.T) {
+	now := time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)
+
+	tests := map[string]func(*testing.T){
+		"Should find transactions by identifier": func(t *testing.T) {
+			// given
+			refundable := NewRefundable(Transaction{ID: "a"}, Transaction{ID: "b"})
+
+			// when
+			found, ok := refundable.find("b")
+			_, missing := refundable.find("c")
+			_, empty := refundable.find("")
+
+			// then
+			assert.True(t, ok)
+			assert.Equal(t, Transaction{ID: "b"}, found)
+			assert.False(t, missing)
+			assert.False(t, empty)
+		},
+		"Should copy transactions instead of changing the ones kept": func(t *testing.T) {
+			// given
+			refundable := NewRefundable(Transaction{ID: "a"}, Transaction{ID: "b"}, Transaction{ID: "c"})
+
+			// when
+			without := refundable.without("b")
+			with := refundable.with(Transaction{ID: "b", Amount: 10})
+
+			// then
+			assert.Equal(t, []Transaction{{ID: "a"}, {ID: "c"}}, without.Transactions())
+			assert.Equal(t, []Transaction{{ID: "a"}, {ID: "b", Amount: 10}, {ID: "c"}}, with.Transactions())
+			assert.Equal(t, []Transaction{{ID: "a"}, {ID: "b"}, {ID: "c"}}, refundable.Transactions())
+		},
+		"Should index the holds not captured yet": func(t *testing.T) {
+			// given
+			refundable := NewRefundable(
+				Transaction{ID: "b", Time: now, Hold: true},
+				Transaction{ID: "a", Time: now, Hold: true},
+				Transaction{ID: "c", Time: now.Add(-time.Hour)},
+			)
+
+			// when
+			captured := refundable.with(Transaction{ID: "b", Time: now})
+
+			// then
+			assert.Equal(t, []Transaction{{ID: "a", Time: now, Hold: true}, {ID: "b", Time: now, Hold: true}}, refundable.Holds())
+			assert.Equal(t, []Transaction{{ID: "a", Time: now, Hold: true}}, captured.Holds())
+			assert.Empty(t, captured.without("a").Holds())
+		},
+		"Should drop captured transactions older than the retention period": func(t *testing.T) {
+			// given
+			old := now.Add(-RefundRetention - time.Minute)
+			ids := idsInOneShard(3)
+			refundable := Refundable{}.
+				with(Transaction{ID: ids[0], Time: old}).
+				with(Transaction{ID: ids[1], Time: old, Hold: true})
+
+			// when
+			res := refundable.with(Transaction{ID: ids[2], Time: now})
+
+			// then
+			_, found := res.find(ids[0])
+			_, held := res.find(ids[1])
+			assert.False(t, found)
+			assert.True(t, held)
+			assert.Equal(t, 2, res.Len())
+		},
+		"Should be empty once every transaction is removed": func(t *testing.T) {
+			// given
+			refundable := Refundable{}.with(Transaction{ID: "a", Hold: true})
+
+			// when
+			res := refundable.without("a")
+
+			// then
+			assert.Equal(t, Refundable{}, res)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func idsInOneShard(count int) []string {
+	var ids []string
+	for i := 0; len(ids) < count; i++ {
+		if id := fmt.Sprintf("t%d", i); shardOf(id) == shardOf("t0") {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type Rule interface {
+	Evaluate(acc Account, tr Transaction, history History) []error
+}
+
+// WindowedRule is implemented by rules looking back at the history, so transactions older than
+// the largest window among the registered rules can be evicted from it.
+type WindowedRule interface {
+	Rule
+	Window() time.Duration
+}
+
+type RuleFunc func(acc Account, tr Transaction, history History) []error
+
+func (f RuleFunc) Evaluate(acc Account, tr Transaction, history History) []error {
+	return f(acc, tr, history)
+}
+
+type RuleRegistry struct {
+	rules []registeredRule
+}
+
+type registeredRule struct {
+	name  string
+	order int
+	rule  Rule
+}
+
+func NewRuleRegistry() *RuleRegistry {
+	return &RuleRegistry{}
+}
+
+func (r *RuleRegistry) Register(name string, order int, rule Rule) error {
+	for _, registered := range r.rules {
+		if registered.name == name {
+			return fmt.Errorf("rule %q already registered", name)
+		}
+	}
+
+	r.rules = append(r.rules, registeredRule{name: name, order: order, rule: rule})
+	sort.SliceStable(r.rules, func(i, j int) bool {
+		return r.rules[i].order < r.rules[j].order
+	})
+	return nil
+}
+
+func (r *RuleRegistry) Names() []string {
+	var names []string
+	for _, registered := range r.rules {
+		names = append(names, registered.name)
+	}
+	return names
+}
+
+func (r *RuleRegistry) Evaluate(acc Account, tr Transaction) []error {
+	var errs []error
+	for _, registered := range r.rules {
+		errs = append(errs, registered.rule.Evaluate(acc, tr, acc.history)...)
+	}
+	return errs
+}
+
+// Window is the largest window looked back by the registered rules.
+func (r *RuleRegistry) Window() time.Duration {
+	var window time.Duration
+	for _, registered := range r.rules {
+		if windowed, ok := registered.rule.(WindowedRule); ok && windowed.Window() > window {
+			window = windowed.Window()
+		}
+	}
+	return window
+}
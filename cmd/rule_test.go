@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterRule(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should register rules sorted by order": func(t *testing.T) {
+			// given
+			r := NewRuleRegistry()
+			noop := RuleFunc(func(Account, Transaction, History) []error { return nil })
+
+			// when
+			_ = r.Register("third", 30, noop)
+			_ = r.Register("first", 10, noop)
+			_ = r.Register("second", 20, noop)
+			_ = r.Register("second-tie", 20, noop)
+
+			// then
+			assert.Equal(t, []string{"first", "second", "second-tie", "third"}, r.Names())
+		},
+		"Should not register a rule with a duplicated name": func(t *testing.T) {
+			// given
+			r := NewRuleRegistry()
+			noop := RuleFunc(func(Account, Transaction, History) []error { return nil })
+			_ = r.Register("rule", 10, noop)
+
+			// when
+			err := r.Register("rule", 20, noop)
+
+			// then
+			assert.Error(t, err)
+			assert.Equal(t, []string{"rule"}, r.Names())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should collect violations from every rule in order": func(t *testing.T) {
+			// given
+			r := NewRuleRegistry()
+			_ = r.Register("second", 20, RuleFunc(func(Account, Transaction, History) []error {
+				return []error{errors.New("second-violation")}
+			}))
+			_ = r.Register("first", 10, RuleFunc(func(Account, Transaction, History) []error {
+				return []error{errors.New("first-violation")}
+			}))
+			_ = r.Register("silent", 15, RuleFunc(func(Account, Transaction, History) []error {
+				return nil
+			}))
+
+			// when
+			errs := r.Evaluate(Account{}, Transaction{})
+
+			// then
+			assert.Equal(t, []error{errors.New("first-violation"), errors.New("second-violation")}, errs)
+		},
+		"Should provide account history to rules": func(t *testing.T) {
+			// given
+			r := NewRuleRegistry()
+			var received History
+			_ = r.Register("history", 10, RuleFunc(func(_ Account, _ Transaction, history History) []error {
+				received = history
+				return nil
+			}))
+			acc := Account{
+				history: NewHistory(Transaction{Merchant: "Alpha"}, Transaction{Merchant: "Beta"}),
+			}
+
+			// when
+			errs := r.Evaluate(acc, Transaction{})
+
+			// then
+			assert.Empty(t, errs)
+			assert.Equal(t, acc.history, received)
+		},
+		"Should look back as far as the largest window among rules": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			r := NewRuleRegistry()
+			_ = r.Register(HighFrequencySmallInterval, HighFrequencySmallIntervalOrder, highFrequencySmallIntervalRule(cfg))
+			when, _ := ParseExpression(`count(1h) > 5`)
+			_ = r.Register("hourly", 100, DeclarativeRule{Name: "hourly", Violation: "hourly", When: when})
+
+			// then
+			assert.Equal(t, time.Hour, r.Window())
+			assert.Equal(t, time.Duration(0), NewRuleRegistry().Window())
+		},
+		"Should look back as far as any account may set its limits": func(t *testing.T) {
+			// given
+			r := NewDefaultRuleRegistry(DefaultConfig())
+
+			// then
+			assert.Equal(t, maxMonthLength, r.Window())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
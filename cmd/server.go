@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Server struct {
+	handler Handler
+}
+
+func NewServer(h Handler) *Server {
+	return &Server{handler: h}
+}
+
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/accounts", s.onlyMethod(http.MethodPost, decodeAndDispatch[Account](s, http.StatusCreated, nil)))
+	mux.HandleFunc("/accounts/", s.byMethod(map[string]http.HandlerFunc{
+		http.MethodGet:   s.findAccount,
+		http.MethodPatch: decodeAndDispatch(s, http.StatusOK, accountIDFromURL),
+	}))
+	mux.HandleFunc("/transactions", s.onlyMethod(http.MethodPost, decodeAndDispatch(s, http.StatusOK, idempotencyKeyFromHeader)))
+	mux.HandleFunc("/refunds", s.onlyMethod(http.MethodPost, decodeAndDispatch[Refund](s, http.StatusOK, nil)))
+	mux.HandleFunc("/reversals", s.onlyMethod(http.MethodPost, decodeAndDispatch[Reversal](s, http.StatusOK, nil)))
+	mux.HandleFunc("/captures", s.onlyMethod(http.MethodPost, decodeAndDispatch[Capture](s, http.StatusOK, nil)))
+	mux.HandleFunc("/cards", s.onlyMethod(http.MethodPost, decodeAndDispatch[CardOperation](s, http.StatusOK, nil)))
+	mux.HandleFunc("/spending-limits", s.onlyMethod(http.MethodPost, decodeAndDispatch[SpendingLimitsUpdate](s, http.StatusOK, nil)))
+	mux.HandleFunc("/velocity-limits", s.onlyMethod(http.MethodPost, decodeAndDispatch[VelocityLimitsUpdate](s, http.StatusOK, nil)))
+	mux.HandleFunc("/merchants", s.onlyMethod(http.MethodPost, decodeAndDispatch[MerchantOperation](s, http.StatusOK, nil)))
+	mux.HandleFunc("/categories", s.onlyMethod(http.MethodPost, decodeAndDispatch[CategoryPolicyUpdate](s, http.StatusOK, nil)))
+	mux.HandleFunc("/similarity-exemptions", s.onlyMethod(http.MethodPost, decodeAndDispatch[SimilarityExemptionsUpdate](s, http.StatusOK, nil)))
+	return http.TimeoutHandler(mux, RequestTimeout, "")
+}
+
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      s.Routes(),
+		ReadTimeout:  RequestTimeout,
+		WriteTimeout: 2 * RequestTimeout,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		done <- srv.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.ListenAndServe()
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return <-done
+}
+
+// decodeAndDispatch answers with the decision taken on the request decoded from the body.
+// complete fills in what is informed outside the body, such as headers or the path.
+func decodeAndDispatch[T accountRequest](s *Server, success int, complete func(*http.Request, *T) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request T
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			s.respond(w, http.StatusBadRequest, Account{}, []error{&InputError{Err: err}})
+			return
+		}
+		if complete != nil {
+			if err := complete(r, &request); err != nil {
+				s.respond(w, http.StatusBadRequest, Account{}, []error{&InputError{Err: err}})
+				return
+			}
+		}
+
+		acc, errs := s.dispatch(request)
+		s.respond(w, statusFor(success, errs), acc, errs)
+	}
+}
+
+func idempotencyKeyFromHeader(r *http.Request, tr *Transaction) error {
+	if tr.IdempotencyKey == "" {
+		tr.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+	return nil
+}
+
+func accountIDFromURL(r *http.Request, au *AccountUpdate) error {
+	id, err := accountIDFromPath(strings.TrimPrefix(r.URL.Path, "/accounts/"))
+	au.AccountID = id
+	return err
+}
+
+func (s *Server) findAccount(w http.ResponseWriter, r *http.Request) {
+	segment := strings.TrimPrefix(r.URL.Path, "/accounts/")
+	if strings.HasSuffix(segment, "/events") {
+		s.listEvents(w, strings.TrimSuffix(segment, "/events"))
+		return
+	}
+	id, err := accountIDFromPath(segment)
+	if err != nil {
+		s.respond(w, http.StatusBadRequest, Account{}, []error{&InputError{Err: err}})
+		return
+	}
+
+	var acc Account
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		acc, err = s.accountAt(id, offset)
+	} else {
+		acc, err = s.handler.db.FindAccount(id)
+	}
+
+	var inputErr *InputError
+	if errors.As(err, &inputErr) {
+		s.respond(w, http.StatusBadRequest, Account{}, []error{err})
+		return
+	}
+
+	if err != nil {
+		s.respond(w, http.StatusNotFound, Account{ID: id}, []error{errors.New(AccountNotInitialized)})
+		return
+	}
+	s.respond(w, http.StatusOK, acc, nil)
+}
+
+// accountAt folds the events of an account up to offset, answering how it looked at that point.
+func (s *Server) accountAt(id int, offset string) (Account, error) {
+	at, err := strconv.Atoi(offset)
+	if err != nil {
+		return Account{}, &InputError{Err: err}
+	}
+	return StateAt(s.handler.events, id, at)
+}
+
+// listEvents answers with every event recorded for an account, in order.
+func (s *Server) listEvents(w http.ResponseWriter, segment string) {
+	id, err := accountIDFromPath(segment)
+	if err != nil {
+		s.respond(w, http.StatusBadRequest, Account{}, []error{&InputError{Err: err}})
+		return
+	}
+
+	events := s.handler.events.AccountEvents(id)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Events []Event `json:"events"`
+	}{events})
+}
+
+func (s *Server) dispatch(request accountRequest) (Account, []error) {
+	decision := <-s.handler.Submit(request)
+	return decision.Account, decision.Errors
+}
+
+func (s *Server) respond(w http.ResponseWriter, status int, acc Account, errs []error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(s.handler.Encode(acc, errs).Bytes())
+}
+
+func (s *Server) onlyMethod(method string, next http.HandlerFunc) http.HandlerFunc {
+	return s.byMethod(map[string]http.HandlerFunc{method: next})
+}
+
+func (s *Server) byMethod(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	var allowed []string
+	for method := range handlers {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		next, ok := handlers[r.Method]
+		if !ok {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func accountIDFromPath(segment string) (int, error) {
+	if segment == "current" {
+		return 0, nil
+	}
+	return strconv.Atoi(segment)
+}
+
+// statusFor answers declines with 422, while failures the client may retry get their own status:
+// 503 when a change could not be stored and 409 when the account kept changing concurrently.
+func statusFor(success int, errs []error) int {
+	status := success
+	for _, err := range errs {
+		var storageErr *StorageError
+		switch {
+		case errors.As(err, &storageErr):
+			return http.StatusServiceUnavailable
+		case errors.Is(err, ErrVersionConflict):
+			status = http.StatusConflict
+		case status != http.StatusConflict:
+			status = http.StatusUnprocessableEntity
+		}
+	}
+	return status
+}
+
+const (
+	RequestTimeout  = 5 * time.Second
+	ShutdownTimeout = 10 * time.Second
+)
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerRoutes(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should create account": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// then
+			assert.Equal(t, http.StatusCreated, res.Code)
+			assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":[]}`, res.Body.String())
+		},
+		"Should not create account when an account is already initialized": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": false, "availableLimit": 200 }`)
+
+			// then
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":["account-already-initialized"]}`, res.Body.String())
+		},
+		"Should authorize transaction": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/transactions", `{ "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" }`)
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":80},"violations":[]}`, res.Body.String())
+		},
+		"Should not authorize transaction with violations": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/transactions", `{ "merchant": "Acme Corporation", "amount": 200, "time": "2020-07-12T10:00:00.000Z" }`)
+
+			// then
+			assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":["insufficient-limit"]}`, res.Body.String())
+		},
+		"Should replay transaction with repeated idempotency key header": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+			body := `{ "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" }`
+			req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(body))
+			req.Header.Set("Idempotency-Key", "order-1")
+			serveRequest(s, http.MethodPost, "/transactions", `{ "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z", "idempotencyKey": "order-1" }`)
+			res := httptest.NewRecorder()
+
+			// when
+			s.Routes().ServeHTTP(res, req)
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":80},"violations":[]}`, res.Body.String())
+		},
+		"Should refund and reverse transactions": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+			serveRequest(s, http.MethodPost, "/transactions", `{ "transactionId": "t1", "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" }`)
+
+			// when
+			refund := serveRequest(s, http.MethodPost, "/refunds", `{ "transactionId": "t1", "amount": 5, "time": "2020-07-12T11:00:00.000Z" }`)
+			reversal := serveRequest(s, http.MethodPost, "/reversals", `{ "transactionId": "t1", "time": "2020-07-12T11:00:00.000Z" }`)
+			missing := serveRequest(s, http.MethodPost, "/reversals", `{ "transactionId": "t1", "time": "2020-07-12T11:00:00.000Z" }`)
+
+			// then
+			assert.Equal(t, http.StatusOK, refund.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":85},"violations":[]}`, refund.Body.String())
+			assert.Equal(t, http.StatusOK, reversal.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":[]}`, reversal.Body.String())
+			assert.Equal(t, http.StatusUnprocessableEntity, missing.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":["original-transaction-not-found"]}`, missing.Body.String())
+		},
+		"Should block card": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/cards", `{ "action": "block", "reason": "lost card" }`)
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":false,"cardStatus":"blocked","blockReason":"lost card","creditLimit":100,"availableLimit":100},"violations":[]}`, res.Body.String())
+		},
+		"Should update spending limits": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/spending-limits", `{ "daily": 50 }`)
+			invalid := serveRequest(s, http.MethodPost, "/spending-limits", `{ "timeZone": "Nowhere" }`)
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100,"spendingLimits":{"daily":50}},"violations":[]}`, res.Body.String())
+			assert.Equal(t, http.StatusUnprocessableEntity, invalid.Code)
+			assert.Contains(t, invalid.Body.String(), InvalidTimeZone)
+		},
+		"Should update account credit limit": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "accountId": 3, "activeCard": true, "availableLimit": 100 }`)
+			serveRequest(s, http.MethodPost, "/transactions", `{ "accountId": 3, "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" }`)
+
+			// when
+			res := serveRequest(s, http.MethodPatch, "/accounts/3", `{ "creditLimit": 300 }`)
+			below := serveRequest(s, http.MethodPatch, "/accounts/3", `{ "creditLimit": 10 }`)
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"accountId":3,"activeCard":true,"cardStatus":"active","creditLimit":300,"availableLimit":280},"violations":[]}`, res.Body.String())
+			assert.Equal(t, http.StatusUnprocessableEntity, below.Code)
+			assert.Contains(t, below.Body.String(), CreditLimitBelowUsage)
+		},
+		"Should reject malformed body": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodPost, "/transactions", `{ "merchant": `)
+
+			// then
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+			assert.Contains(t, res.Body.String(), InvalidInput)
+		},
+		"Should get current account": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "activeCard": true, "availableLimit": 100 }`)
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/current", "")
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":[]}`, res.Body.String())
+		},
+		"Should get account by identifier": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "accountId": 3, "activeCard": true, "availableLimit": 300 }`)
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/3", "")
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"accountId":3,"activeCard":true,"cardStatus":"active","creditLimit":300,"availableLimit":300},"violations":[]}`, res.Body.String())
+		},
+		"Should get account as it was at an event offset": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "accountId": 3, "activeCard": true, "availableLimit": 300 }`)
+			serveRequest(s, http.MethodPost, "/transactions", `{ "accountId": 3, "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" }`)
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/3?offset=1", "")
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"account":{"accountId":3,"activeCard":true,"cardStatus":"active","creditLimit":300,"availableLimit":300},"violations":[]}`, res.Body.String())
+		},
+		"Should reject malformed event offset": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/3?offset=first", "")
+
+			// then
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+			assert.Contains(t, res.Body.String(), InvalidInput)
+		},
+		"Should list events of an account": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			serveRequest(s, http.MethodPost, "/accounts", `{ "accountId": 3, "activeCard": true, "availableLimit": 10 }`)
+			serveRequest(s, http.MethodPost, "/accounts", `{ "accountId": 4, "activeCard": true, "availableLimit": 10 }`)
+			serveRequest(s, http.MethodPost, "/transactions", `{ "accountId": 3, "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" }`)
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/3/events", "")
+
+			// then
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.JSONEq(t, `{"events":[
+				{"offset":1,"version":1,"type":"AccountCreated","accountId":3,"account":{"accountId":3,"activeCard":true,"cardStatus":"active","creditLimit":10,"availableLimit":10}},
+				{"offset":3,"version":1,"type":"TransactionDeclined","accountId":3,"transaction":{"accountId":3,"merchant":"Acme Corporation","amount":20,"time":"2020-07-12T10:00:00Z"},"violations":["insufficient-limit"]}
+			]}`, res.Body.String())
+		},
+		"Should not get current account when there are no accounts": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/current", "")
+
+			// then
+			assert.Equal(t, http.StatusNotFound, res.Code)
+			assert.Contains(t, res.Body.String(), AccountNotInitialized)
+		},
+		"Should reject malformed account identifier": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/accounts/abc", "")
+
+			// then
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+		},
+		"Should keep limits consistent under concurrent requests": func(t *testing.T) {
+			// given
+			cfg := DefaultConfig()
+			cfg.Workers = 4
+			cfg.MaxFrequencyPerInterval = 1000
+			h, _ := initHandler(cfg, true)
+			defer h.Close()
+			s := NewServer(h)
+			for id := 1; id <= 4; id++ {
+				serveRequest(s, http.MethodPost, "/accounts", fmt.Sprintf(`{ "accountId": %d, "activeCard": true, "availableLimit": 1000 }`, id))
+			}
+
+			// when
+			var wg sync.WaitGroup
+			for i := 0; i < 200; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					serveRequest(s, http.MethodPost, "/transactions", fmt.Sprintf(
+						`{ "accountId": %d, "merchant": "Merchant %d", "amount": 10, "time": "2020-07-12T10:00:00.000Z" }`, i%4+1, i))
+				}(i)
+			}
+			wg.Wait()
+
+			// then
+			for id := 1; id <= 4; id++ {
+				acc, _ := h.db.FindAccount(id)
+				assert.Equal(t, Units(500), acc.AvailableLimit)
+				assert.Equal(t, 50, acc.history.Len())
+			}
+		},
+		"Should reject unsupported method": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodGet, "/transactions", "")
+
+			// then
+			assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+			assert.Equal(t, http.MethodPost, res.Header().Get("Allow"))
+		},
+		"Should list every supported method": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+
+			// when
+			res := serveRequest(s, http.MethodDelete, "/accounts/1", "")
+
+			// then
+			assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+			assert.Equal(t, "GET, PATCH", res.Header().Get("Allow"))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestServerListenAndServe(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should shut down gracefully when context is cancelled": func(t *testing.T) {
+			// given
+			s := NewServer(newTestHandler())
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			addr := listener.Addr().String()
+			listener.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- s.ListenAndServe(ctx, addr)
+			}()
+
+			// when
+			cancel()
+
+			// then
+			select {
+			case err := <-done:
+				assert.NoError(t, err)
+			case <-time.After(ShutdownTimeout):
+				t.Fatal("server did not shut down")
+			}
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestStatusFor(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should answer successful requests with the informed status": func(t *testing.T) {
+			assert.Equal(t, http.StatusCreated, statusFor(http.StatusCreated, nil))
+		},
+		"Should answer declines as unprocessable": func(t *testing.T) {
+			assert.Equal(t, http.StatusUnprocessableEntity, statusFor(http.StatusOK, []error{errors.New(InsufficientLimit)}))
+		},
+		"Should answer version conflicts as conflicts": func(t *testing.T) {
+			assert.Equal(t, http.StatusConflict, statusFor(http.StatusOK, []error{ErrVersionConflict}))
+		},
+		"Should answer storage failures as unavailable": func(t *testing.T) {
+			errs := []error{errors.New(InsufficientLimit), &StorageError{Err: errors.New("disk full")}}
+			assert.Equal(t, http.StatusServiceUnavailable, statusFor(http.StatusOK, errs))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func serveRequest(s *Server, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	res := httptest.NewRecorder()
+	s.Routes().ServeHTTP(res, req)
+	return res
+}
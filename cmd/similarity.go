@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Similarity decides whether two transactions are the same purchase informed twice. By default
+// merchants and amounts must be equal, but merchants can be compared by their canonical names
+// and amounts may differ up to a percentage of the transaction amount.
+type Similarity struct {
+	merchants        *MerchantNormalizer
+	tolerancePercent int64
+}
+
+func (s Similarity) isExact() bool {
+	return s.merchants == nil && s.tolerancePercent == 0
+}
+
+func (s Similarity) similar(tr Transaction, other Transaction) bool {
+	if s.isExact() {
+		return tr.isSimilar(other)
+	}
+	return s.sameMerchant(tr.Merchant, other.Merchant) && s.closeAmounts(tr.Amount, other.Amount)
+}
+
+func (s Similarity) sameMerchant(merchant string, other string) bool {
+	if s.merchants == nil {
+		return merchant == other
+	}
+	return s.merchants.Canonical(merchant) == s.merchants.Canonical(other)
+}
+
+func (s Similarity) closeAmounts(amount Money, other Money) bool {
+	if amount == other {
+		return true
+	}
+	difference, err := amount.Sub(other)
+	if err != nil {
+		return false
+	}
+	if difference < 0 {
+		difference = -difference
+	}
+	// split the amount so multiplying it by the percentage never overflows
+	tolerance := amount/100*Money(s.tolerancePercent) + amount%100*Money(s.tolerancePercent)/100
+	return difference >= 0 && difference <= tolerance
+}
+
+// countSince counts the transactions at or after from that are similar to tr, using the history
+// index when similarity is exact.
+func (s Similarity) countSince(history History, tr Transaction, from time.Time) int {
+	if s.isExact() {
+		return history.countSince(tr, from).similarity
+	}
+	count := 0
+	for _, t := range history.after(from) {
+		if s.similar(tr, t) {
+			count++
+		}
+	}
+	return count
+}
+
+// SimilarityExemption lets merchants matching the pattern, such as transit taps or vending machines,
+// repeat a transaction up to their own limit within their own interval instead of the configured
+// ones. Patterns match merchant names like merchant lists do.
+type SimilarityExemption struct {
+	Merchant                 string `json:"merchant"`
+	MaxSimilarityPerInterval int    `json:"maxSimilarityPerInterval"`
+	IntervalMinutes          int    `json:"intervalMinutes"`
+}
+
+// SimilarityExemptionsUpdate replaces the similarity exemptions of an account.
+type SimilarityExemptionsUpdate struct {
+	AccountID  int                   `json:"accountId,omitempty"`
+	Exemptions []SimilarityExemption `json:"exemptions,omitempty"`
+}
+
+func (eu SimilarityExemptionsUpdate) accountID() int {
+	return eu.AccountID
+}
+
+// validateExemptions requires a pattern, a limit and an interval within MaxVelocityIntervalMinutes
+// for every exemption, as the doubled transaction rule only looks back that far for accounts.
+func validateExemptions(exemptions []SimilarityExemption) error {
+	for _, e := range exemptions {
+		if normalizePattern(e.Merchant) == "" {
+			return errors.New(MerchantPatternRequired)
+		}
+		if e.MaxSimilarityPerInterval <= 0 || e.IntervalMinutes <= 0 || e.IntervalMinutes > MaxVelocityIntervalMinutes {
+			return errors.New(InvalidSimilarityExemption)
+		}
+	}
+	return nil
+}
+
+func (e SimilarityExemption) interval() time.Duration {
+	return time.Duration(e.IntervalMinutes) * time.Minute
+}
+
+// exemptionFor returns the first exemption matching the merchant, looking into each list in turn.
+func exemptionFor(merchant string, lists ...[]SimilarityExemption) (SimilarityExemption, bool) {
+	name := normalizeMerchant(merchant)
+	for _, exemptions := range lists {
+		for _, e := range exemptions {
+			if matchesPattern(e.Merchant, name) {
+				return e, true
+			}
+		}
+	}
+	return SimilarityExemption{}, false
+}
+
+// MerchantNormalizer reduces the names acquirers send for the same merchant to a canonical one:
+// case and punctuation are folded, reference numbers following a * and trailing store numbers or
+// company suffixes are stripped, and aliases are replaced by the names they stand for.
+type MerchantNormalizer struct {
+	aliases map[string]string
+}
+
+// NewMerchantNormalizer takes aliases mapping alternative names to the merchant they stand for.
+func NewMerchantNormalizer(aliases map[string]string) *MerchantNormalizer {
+	n := &MerchantNormalizer{aliases: map[string]string{}}
+	for alias, merchant := range aliases {
+		n.aliases[stripDescriptor(alias)] = stripDescriptor(merchant)
+	}
+	return n
+}
+
+func (n *MerchantNormalizer) Canonical(merchant string) string {
+	name := stripDescriptor(merchant)
+	if canonical, found := n.aliases[name]; found {
+		return canonical
+	}
+	return name
+}
+
+func stripDescriptor(merchant string) string {
+	name := strings.ToLower(merchant)
+	if i := strings.Index(name, "*"); i > 0 && strings.IndexFunc(name[i:], unicode.IsDigit) >= 0 {
+		name = name[:i]
+	}
+
+	words := strings.Fields(normalizeMerchant(name))
+	last := len(words)
+	for last > 1 && (isDigits(words[last-1]) || companySuffixes[words[last-1]]) {
+		last--
+	}
+	if last == 0 {
+		return normalizeMerchant(merchant)
+	}
+	return strings.Join(words[:last], " ")
+}
+
+var companySuffixes = map[string]bool{
+	"co":          true,
+	"corp":        true,
+	"corporation": true,
+	"inc":         true,
+	"llc":         true,
+	"ltd":         true,
+	"ltda":        true,
+	"sa":          true,
+}
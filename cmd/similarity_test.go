@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerchantNormalizer(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should strip reference numbers, store numbers and company suffixes": func(t *testing.T) {
+			// given
+			n := NewMerchantNormalizer(nil)
+
+			// then
+			assert.Equal(t, "acme", n.Canonical("ACME CORP*123ABC"))
+			assert.Equal(t, "acme", n.Canonical("Acme Corporation"))
+			assert.Equal(t, "coffee shop", n.Canonical("Coffee Shop #0042"))
+			assert.Equal(t, "streaming co monthly", n.Canonical("STREAMING CO*MONTHLY"))
+			assert.Equal(t, "inc", n.Canonical("Inc."))
+		},
+		"Should replace aliases by the merchant they stand for": func(t *testing.T) {
+			// given
+			n := NewMerchantNormalizer(map[string]string{"AMZN Mktp": "Amazon"})
+
+			// then
+			assert.Equal(t, "amazon", n.Canonical("AMZN MKTP*2K4"))
+			assert.Equal(t, "amazon", n.Canonical("Amazon Inc"))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestSimilarityExemptions(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should take the first exemption matching the merchant": func(t *testing.T) {
+			// given
+			account := []SimilarityExemption{{Merchant: "City Transit*", MaxSimilarityPerInterval: 4, IntervalMinutes: 10}}
+			configured := []SimilarityExemption{
+				{Merchant: "city transit*", MaxSimilarityPerInterval: 2, IntervalMinutes: 1},
+				{Merchant: "Vending Co", MaxSimilarityPerInterval: 3, IntervalMinutes: 5},
+			}
+
+			// when
+			transit, foundTransit := exemptionFor("CITY TRANSIT 0042", account, configured)
+			vending, foundVending := exemptionFor("vending-co", account, configured)
+			_, foundGrocery := exemptionFor("Grocery", account, configured)
+
+			// then
+			assert.True(t, foundTransit)
+			assert.Equal(t, account[0], transit)
+			assert.True(t, foundVending)
+			assert.Equal(t, configured[1], vending)
+			assert.False(t, foundGrocery)
+		},
+		"Should reject exemptions without pattern, limit or a kept interval": func(t *testing.T) {
+			// then
+			assert.NoError(t, validateExemptions(nil))
+			assert.Equal(t, errors.New(MerchantPatternRequired), validateExemptions([]SimilarityExemption{{Merchant: " * ", MaxSimilarityPerInterval: 2, IntervalMinutes: 1}}))
+			assert.Equal(t, errors.New(InvalidSimilarityExemption), validateExemptions([]SimilarityExemption{{Merchant: "Transit", IntervalMinutes: 1}}))
+			assert.Equal(t, errors.New(InvalidSimilarityExemption), validateExemptions([]SimilarityExemption{{Merchant: "Transit", MaxSimilarityPerInterval: 2}}))
+			assert.Equal(t, errors.New(InvalidSimilarityExemption), validateExemptions([]SimilarityExemption{{Merchant: "Transit", MaxSimilarityPerInterval: 2, IntervalMinutes: MaxVelocityIntervalMinutes + 1}}))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	history := NewHistory(
+		Transaction{Merchant: "Acme Corp*123", Amount: Units(100), Time: time.Date(2020, 7, 12, 10, 0, 0, 0, time.UTC)},
+		Transaction{Merchant: "Acme", Amount: Units(104), Time: time.Date(2020, 7, 12, 10, 1, 0, 0, time.UTC)},
+		Transaction{Merchant: "Acme", Amount: Units(100), Time: time.Date(2020, 7, 12, 10, 2, 0, 0, time.UTC)},
+	)
+	tr := Transaction{Merchant: "Acme", Amount: Units(100), Time: time.Date(2020, 7, 12, 10, 3, 0, 0, time.UTC)}
+
+	tests := map[string]func(*testing.T){
+		"Should only count equal merchants and amounts by default": func(t *testing.T) {
+			// given
+			s := Similarity{}
+
+			// then
+			assert.Equal(t, 1, s.countSince(history, tr, tr.Time.Add(-time.Hour)))
+		},
+		"Should count merchants by their canonical names": func(t *testing.T) {
+			// given
+			s := Similarity{merchants: NewMerchantNormalizer(nil)}
+
+			// then
+			assert.Equal(t, 2, s.countSince(history, tr, tr.Time.Add(-time.Hour)))
+			assert.Equal(t, 1, s.countSince(history, tr, time.Date(2020, 7, 12, 10, 1, 0, 0, time.UTC)))
+		},
+		"Should count amounts within the tolerance": func(t *testing.T) {
+			// given
+			s := Similarity{merchants: NewMerchantNormalizer(nil), tolerancePercent: 5}
+
+			// then
+			assert.Equal(t, 3, s.countSince(history, tr, tr.Time.Add(-time.Hour)))
+			assert.True(t, s.closeAmounts(Units(100), Units(95)))
+			assert.False(t, s.closeAmounts(Units(100), Units(94)))
+			assert.False(t, s.closeAmounts(math.MaxInt64, -math.MaxInt64))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
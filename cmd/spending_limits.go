@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// SpendingLimits caps the amount of each transaction and the amounts authorized per calendar day
+// and month, in the account currency. Days and months start at midnight in TimeZone, an IANA
+// name that defaults to UTC. Caps left at zero are off.
+type SpendingLimits struct {
+	PerTransaction Money  `json:"perTransaction,omitempty"`
+	Daily          Money  `json:"daily,omitempty"`
+	Monthly        Money  `json:"monthly,omitempty"`
+	TimeZone       string `json:"timeZone,omitempty"`
+}
+
+// SpendingLimitsUpdate replaces the spending limits of an account.
+type SpendingLimitsUpdate struct {
+	AccountID int `json:"accountId,omitempty"`
+	SpendingLimits
+}
+
+func (su SpendingLimitsUpdate) accountID() int {
+	return su.AccountID
+}
+
+func (l SpendingLimits) validate() error {
+	if l.PerTransaction < 0 || l.Daily < 0 || l.Monthly < 0 {
+		return errors.New(InvalidAmount)
+	}
+	if _, err := loadLocation(l.TimeZone); err != nil {
+		return errors.New(InvalidTimeZone)
+	}
+	return nil
+}
+
+// exceeds reports whether tr takes the amounts authorized within the period holding it, among the
+// transactions counted, above limit. Overflowing amounts always exceed it.
+func (l SpendingLimits) exceeds(limit Money, history History, tr Transaction, period func(time.Time) (time.Time, time.Time), counts func(Transaction) bool) bool {
+	if limit <= 0 {
+		return false
+	}
+	spent, err := l.spentWithin(history, tr, period, counts)
+	return err != nil || spent > limit
+}
+
+// spentWithin sums the amounts authorized from the start of the period holding tr, as given by
+// period, up to the start of the next one.
+func (l SpendingLimits) spentWithin(history History, tr Transaction, period func(time.Time) (time.Time, time.Time), counts func(Transaction) bool) (Money, error) {
+	location, _ := loadLocation(l.TimeZone)
+	from, to := period(tr.Time.In(location))
+
+	spent := tr.Amount
+	for _, t := range history.Between(from, to.Add(-time.Nanosecond)) {
+		if !counts(t) {
+			continue
+		}
+		var err error
+		if spent, err = spent.Add(t.Amount); err != nil {
+			return 0, err
+		}
+	}
+	return spent, nil
+}
+
+func anyTransaction(Transaction) bool {
+	return true
+}
+
+func calendarDay(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+func calendarMonth(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+var locations sync.Map
+
+// loadLocation caches time zones, as loading them reads the time zone database.
+func loadLocation(name string) (*time.Location, error) {
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+	return location, nil
+}
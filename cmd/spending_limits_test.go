@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpendingLimitRules(t *testing.T) {
+	history := NewHistory(
+		Transaction{Merchant: "Alpha", Amount: Units(50), Time: time.Date(2020, 7, 12, 2, 0, 0, 0, time.UTC)},
+		Transaction{Merchant: "Beta", Amount: Units(30), Time: time.Date(2020, 7, 12, 12, 0, 0, 0, time.UTC)},
+		Transaction{Merchant: "Gamma", Amount: Units(100), Time: time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)},
+	)
+	tr := Transaction{Merchant: "Delta", Amount: Units(30), Time: time.Date(2020, 7, 12, 20, 0, 0, 0, time.UTC)}
+
+	tests := map[string]func(*testing.T){
+		"Should accept any transaction without spending limits": func(t *testing.T) {
+			// given
+			acc := Account{SpendingLimits: &SpendingLimits{}}
+
+			// then
+			assert.Empty(t, transactionLimitRule(Account{}, tr, history))
+			assert.Empty(t, dailyLimitRule(Account{}, tr, history))
+			assert.Empty(t, monthlyLimitRule(acc, tr, history))
+		},
+		"Should detect transaction above the per transaction limit": func(t *testing.T) {
+			// given
+			acc := Account{SpendingLimits: &SpendingLimits{PerTransaction: Units(30)}}
+
+			// when
+			within := transactionLimitRule(acc, tr, history)
+			above := transactionLimitRule(acc, Transaction{Amount: Units(30) + 1}, history)
+
+			// then
+			assert.Empty(t, within)
+			assert.Equal(t, []error{errors.New(TransactionLimitExceeded)}, above)
+		},
+		"Should sum the amounts authorized on the same calendar day": func(t *testing.T) {
+			// given
+			acc := Account{SpendingLimits: &SpendingLimits{Daily: Units(110)}}
+
+			// when
+			within := dailyLimitRule(acc, tr, history)
+			above := dailyLimitRule(acc, Transaction{Amount: Units(31), Time: tr.Time}, history)
+
+			// then
+			assert.Empty(t, within)
+			assert.Equal(t, []error{errors.New(DailyLimitExceeded)}, above)
+		},
+		"Should start calendar days at midnight in the account time zone": func(t *testing.T) {
+			// given
+			utc := Account{SpendingLimits: &SpendingLimits{Daily: Units(60)}}
+			saoPaulo := Account{SpendingLimits: &SpendingLimits{Daily: Units(60), TimeZone: "America/Sao_Paulo"}}
+
+			// then
+			assert.Equal(t, []error{errors.New(DailyLimitExceeded)}, dailyLimitRule(utc, tr, history))
+			assert.Empty(t, dailyLimitRule(saoPaulo, tr, history))
+		},
+		"Should sum the amounts authorized on the same calendar month": func(t *testing.T) {
+			// given
+			acc := Account{SpendingLimits: &SpendingLimits{Monthly: Units(110)}}
+			saoPaulo := Account{SpendingLimits: &SpendingLimits{Monthly: Units(110), TimeZone: "America/Sao_Paulo"}}
+			lastDayInSaoPaulo := Transaction{Amount: Units(11), Time: time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC)}
+
+			// then
+			assert.Empty(t, monthlyLimitRule(acc, tr, history))
+			assert.Equal(t, []error{errors.New(MonthlyLimitExceeded)}, monthlyLimitRule(acc, Transaction{Amount: Units(31), Time: tr.Time}, history))
+			assert.Empty(t, monthlyLimitRule(acc, lastDayInSaoPaulo, history))
+			assert.Equal(t, []error{errors.New(MonthlyLimitExceeded)}, monthlyLimitRule(saoPaulo, lastDayInSaoPaulo, history))
+		},
+		"Should detect limits exceeded instead of overflowing": func(t *testing.T) {
+			// given
+			acc := Account{SpendingLimits: &SpendingLimits{Daily: Money(math.MaxInt64), Monthly: Money(math.MaxInt64)}}
+			huge := Transaction{Amount: Money(math.MaxInt64), Time: tr.Time}
+
+			// then
+			assert.Equal(t, []error{errors.New(DailyLimitExceeded)}, dailyLimitRule(acc, huge, history))
+			assert.Equal(t, []error{errors.New(MonthlyLimitExceeded)}, monthlyLimitRule(acc, huge, history))
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+)
+
+// Stream handles one request per line of in, writing the responses to out in the same order.
+// Requests are submitted as soon as they are read, so lines of different accounts are processed
+// in parallel while a separate goroutine waits for their decisions and writes them. Once ctx is
+// done no further line is submitted, but the decisions already submitted are still written.
+func (h *Handler) Stream(ctx context.Context, in io.Reader, out io.Writer) error {
+	decisions := make(chan (<-chan Decision), poolQueueSize)
+	written := make(chan error, 1)
+	go func() {
+		written <- h.write(out, decisions)
+	}()
+
+	err := h.read(ctx, in, decisions)
+	close(decisions)
+	if writeErr := <-written; err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// read scans in on its own goroutine, as a blocked read cannot be interrupted when ctx is done.
+func (h *Handler) read(ctx context.Context, in io.Reader, decisions chan<- (<-chan Decision)) error {
+	lines := make(chan []byte)
+	scanned := make(chan error, 1)
+	go func() {
+		scanned <- scanLines(ctx, in, lines)
+		close(lines)
+	}()
+
+	for lineNumber := 1; ; lineNumber++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				return <-scanned
+			}
+			if len(line) > 0 {
+				decisions <- h.process(lineNumber, line)
+			}
+		}
+	}
+}
+
+func scanLines(ctx context.Context, in io.Reader, lines chan<- []byte) error {
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if len(line) > 0 {
+			select {
+			case lines <- bytes.TrimSpace(line):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// write flushes only when no decision is pending, so interactive sessions get immediate
+// feedback while batches keep the output buffered. Decisions are still drained after a
+// failed write so reading is never blocked.
+func (h *Handler) write(out io.Writer, decisions <-chan (<-chan Decision)) error {
+	writer := bufio.NewWriter(out)
+	var err error
+	for pending := range decisions {
+		decision := <-pending
+		if err != nil {
+			continue
+		}
+		_, err = writer.Write(h.Encode(decision.Account, decision.Errors).Bytes())
+		if err == nil && len(decisions) == 0 {
+			err = writer.Flush()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (h *Handler) process(lineNumber int, line []byte) <-chan Decision {
+	request, err := h.Decode(bytes.NewReader(line))
+	if err != nil {
+		decision := make(chan Decision, 1)
+		decision <- Decision{Account: Account{}, Errors: []error{&InputError{Line: lineNumber, Err: err}}}
+		return decision
+	}
+	return h.Submit(request)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	tests := map[string]func(*testing.T){
+		"Should process every line until end of input": func(t *testing.T) {
+			// given
+			h := newTestHandler()
+			stdin := strings.NewReader(
+				`{ "account": { "activeCard": true, "availableLimit": 100 } }` + "\n" +
+					`{ "transaction": { "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" } }`)
+			var stdout bytes.Buffer
+
+			// when
+			err := h.Stream(context.Background(), stdin, &stdout)
+
+			// then
+			assert.NoError(t, err)
+			lines := readLines(&stdout)
+			assert.Len(t, lines, 2)
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":[]}`, lines[0])
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":80},"violations":[]}`, lines[1])
+		},
+		"Should report malformed lines and keep processing": func(t *testing.T) {
+			// given
+			h := newTestHandler()
+			stdin := strings.NewReader(
+				`{ "account": { "activeCard": true, "availableLimit": 100 } }` + "\n" +
+					`{ "transaction": { "merchant": ` + "\n" +
+					`{ "transaction": { "merchant": "Acme Corporation", "amount": 20, "time": "2020-07-12T10:00:00.000Z" } }` + "\n")
+			var stdout bytes.Buffer
+
+			// when
+			err := h.Stream(context.Background(), stdin, &stdout)
+
+			// then
+			assert.NoError(t, err)
+			lines := readLines(&stdout)
+			assert.Len(t, lines, 3)
+			assert.JSONEq(t, `{"account":{"activeCard":false,"availableLimit":0},"violations":["invalid-input"],"input":{"line":2,"reason":"unexpected EOF"}}`, lines[1])
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":80},"violations":[]}`, lines[2])
+		},
+		"Should report lines without a known operation": func(t *testing.T) {
+			// given
+			h := newTestHandler()
+			stdin := strings.NewReader(`{}` + "\n" + `{ "transacton": { "merchant": "Acme Corporation", "amount": 20 } }` + "\n")
+			var stdout bytes.Buffer
+
+			// when
+			err := h.Stream(context.Background(), stdin, &stdout)
+
+			// then
+			assert.NoError(t, err)
+			lines := readLines(&stdout)
+			assert.Len(t, lines, 2)
+			assert.JSONEq(t, `{"account":{"activeCard":false,"availableLimit":0},"violations":["invalid-input"],"input":{"line":1,"reason":"unknown operation"}}`, lines[0])
+			assert.JSONEq(t, `{"account":{"activeCard":false,"availableLimit":0},"violations":["invalid-input"],"input":{"line":2,"reason":"unknown operation"}}`, lines[1])
+		},
+		"Should skip blank lines": func(t *testing.T) {
+			// given
+			h := newTestHandler()
+			stdin := strings.NewReader("\n   \n" + `{ "account": { "activeCard": true, "availableLimit": 100 } }` + "\n\n")
+			var stdout bytes.Buffer
+
+			// when
+			err := h.Stream(context.Background(), stdin, &stdout)
+
+			// then
+			assert.NoError(t, err)
+			assert.Len(t, readLines(&stdout), 1)
+		},
+		"Should stop reading once cancelled and write what was processed": func(t *testing.T) {
+			// given
+			h := newTestHandler()
+			stdin, input := io.Pipe()
+			defer input.Close()
+			output, stdout := io.Pipe()
+			ctx, cancel := context.WithCancel(context.Background())
+			streamed := make(chan error, 1)
+			go func() {
+				streamed <- h.Stream(ctx, stdin, stdout)
+				stdout.Close()
+			}()
+			_, err := io.WriteString(input, `{ "account": { "activeCard": true, "availableLimit": 100 } }`+"\n")
+			assert.NoError(t, err)
+			responses := bufio.NewReader(output)
+			first, err := responses.ReadString('\n')
+			assert.NoError(t, err)
+
+			// when
+			cancel()
+
+			// then
+			select {
+			case err := <-streamed:
+				assert.NoError(t, err)
+			case <-time.After(time.Second):
+				t.Fatal("stream kept reading after being cancelled")
+			}
+			assert.JSONEq(t, `{"account":{"activeCard":true,"cardStatus":"active","creditLimit":100,"availableLimit":100},"violations":[]}`, first)
+			rest, err := io.ReadAll(responses)
+			assert.NoError(t, err)
+			assert.Empty(t, rest)
+		},
+		"Should stop on empty input": func(t *testing.T) {
+			// given
+			h := newTestHandler()
+			var stdout bytes.Buffer
+
+			// when
+			err := h.Stream(context.Background(), strings.NewReader(""), &stdout)
+
+			// then
+			assert.NoError(t, err)
+			assert.Empty(t, stdout.String())
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}
+
+func readLines(buffer *bytes.Buffer) []string {
+	var lines []string
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
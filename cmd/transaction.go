@@ -4,10 +4,49 @@ import (
 	"time"
 )
 
+// Transaction debits its amount right away unless it is a Hold, which only reserves the amount until
+// it is captured or expires. AllowPartial approves it up to the available limit instead of declining
+// with insufficient-limit. Category is never informed, but resolved from the MCC or the merchant
+// when the transaction is authorized.
 type Transaction struct {
-	Merchant string    `json:"merchant"`
-	Amount   int       `json:"amount"`
-	Time     time.Time `json:"time"`
+	ID             string    `json:"transactionId,omitempty"`
+	AccountID      int       `json:"accountId,omitempty"`
+	Merchant       string    `json:"merchant"`
+	MCC            string    `json:"mcc,omitempty"`
+	Category       string    `json:"category,omitempty"`
+	Amount         Money     `json:"amount"`
+	Currency       Currency  `json:"currency,omitempty"`
+	Time           time.Time `json:"time"`
+	IdempotencyKey string    `json:"idempotencyKey,omitempty"`
+	Hold           bool      `json:"hold,omitempty"`
+	AllowPartial   bool      `json:"allowPartial,omitempty"`
+	refunded       Money
+}
+
+func (tr Transaction) accountID() int {
+	return tr.AccountID
+}
+
+// Approval reports a transaction approved for less than the requested amount, both in the
+// account currency.
+type Approval struct {
+	Status          string `json:"status"`
+	RequestedAmount Money  `json:"requestedAmount"`
+	ApprovedAmount  Money  `json:"approvedAmount"`
+}
+
+type Decision struct {
+	Account Account
+	Errors  []error
+}
+
+// response leaves out of the decision the state only kept to evaluate later requests, keeping
+// what is answered for the request.
+func (d Decision) response() Decision {
+	d.Account.history = History{}
+	d.Account.refundable = Refundable{}
+	d.Account.version = 0
+	return d
 }
 
 func (tr *Transaction) isSimilar(other Transaction) bool {
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// VelocityLimits caps, per account, the sum of the amounts and the number of distinct merchants
+// authorized within sliding windows ending at each transaction. Limits left at zero are off.
+type VelocityLimits struct {
+	MaxAmount               Money `json:"maxAmount,omitempty"`
+	AmountIntervalMinutes   int   `json:"amountIntervalMinutes,omitempty"`
+	MaxMerchants            int   `json:"maxMerchants,omitempty"`
+	MerchantIntervalMinutes int   `json:"merchantIntervalMinutes,omitempty"`
+}
+
+// VelocityLimitsUpdate replaces the velocity limits of an account.
+type VelocityLimitsUpdate struct {
+	AccountID int `json:"accountId,omitempty"`
+	VelocityLimits
+}
+
+func (vu VelocityLimitsUpdate) accountID() int {
+	return vu.AccountID
+}
+
+func (l VelocityLimits) validate() error {
+	if l.MaxAmount < 0 {
+		return errors.New(InvalidAmount)
+	}
+	if !validVelocityLimit(int64(l.MaxAmount), l.AmountIntervalMinutes) || !validVelocityLimit(int64(l.MaxMerchants), l.MerchantIntervalMinutes) {
+		return errors.New(InvalidVelocityLimits)
+	}
+	return nil
+}
+
+// validVelocityLimit requires an interval within MaxVelocityIntervalMinutes along with every limit
+// set, as the velocity rules only look back that far.
+func validVelocityLimit(max int64, intervalMinutes int) bool {
+	if max == 0 && intervalMinutes == 0 {
+		return true
+	}
+	return max > 0 && intervalMinutes > 0 && intervalMinutes <= MaxVelocityIntervalMinutes
+}
+
+// within returns the transactions authorized in the given minutes up to tr.
+func within(history History, tr Transaction, intervalMinutes int) []Transaction {
+	return history.Between(tr.Time.Add(-time.Duration(intervalMinutes)*time.Minute), tr.Time)
+}
+
+func amountWithin(history History, tr Transaction, intervalMinutes int) (Money, error) {
+	spent := tr.Amount
+	for _, t := range within(history, tr, intervalMinutes) {
+		var err error
+		if spent, err = spent.Add(t.Amount); err != nil {
+			return 0, err
+		}
+	}
+	return spent, nil
+}
+
+func merchantsWithin(history History, tr Transaction, intervalMinutes int) int {
+	merchants := map[string]bool{tr.Merchant: true}
+	for _, t := range within(history, tr, intervalMinutes) {
+		merchants[t.Merchant] = true
+	}
+	return len(merchants)
+}
+
+const MaxVelocityIntervalMinutes = 24 * 60
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVelocityLimitRules(t *testing.T) {
+	history := NewHistory(
+		Transaction{Merchant: "Alpha", Amount: Units(200), Time: time.Date(2020, 7, 12, 9, 55, 0, 0, time.UTC)},
+		Transaction{Merchant: "Beta", Amount: Units(200), Time: time.Date(2020, 7, 12, 10, 1, 0, 0, time.UTC)},
+		Transaction{Merchant: "Gamma", Amount: Units(1), Time: time.Date(2020, 7, 12, 10, 2, 0, 0, time.UTC)},
+		Transaction{Merchant: "Gamma", Amount: Units(1), Time: time.Date(2020, 7, 12, 10, 3, 0, 0, time.UTC)},
+	)
+	tr := Transaction{Merchant: "Delta", Amount: Units(98), Time: time.Date(2020, 7, 12, 10, 5, 0, 0, time.UTC)}
+
+	tests := map[string]func(*testing.T){
+		"Should accept any transaction without velocity limits": func(t *testing.T) {
+			// then
+			assert.Empty(t, highAmountSmallIntervalRule(Account{}, tr, history))
+			assert.Empty(t, manyMerchantsSmallIntervalRule(Account{VelocityLimits: &VelocityLimits{}}, tr, history))
+		},
+		"Should sum the amounts authorized within the interval": func(t *testing.T) {
+			// given
+			acc := Account{VelocityLimits: &VelocityLimits{MaxAmount: Units(300), AmountIntervalMinutes: 5}}
+			longer := Account{VelocityLimits: &VelocityLimits{MaxAmount: Units(300), AmountIntervalMinutes: 10}}
+
+			// then
+			assert.Empty(t, highAmountSmallIntervalRule(acc, tr, history))
+			assert.Equal(t, []error{errors.New(HighAmountSmallInterval)}, highAmountSmallIntervalRule(acc, Transaction{Amount: Units(99), Time: tr.Time}, history))
+			assert.Equal(t, []error{errors.New(HighAmountSmallInterval)}, highAmountSmallIntervalRule(longer, tr, history))
+		},
+		"Should count distinct merchants within the interval": func(t *testing.T) {
+			// given
+			acc := Account{VelocityLimits: &VelocityLimits{MaxMerchants: 2, MerchantIntervalMinutes: 5}}
+
+			// when
+			repeated := manyMerchantsSmallIntervalRule(acc, Transaction{Merchant: "Gamma", Time: tr.Time}, history)
+			distinct := manyMerchantsSmallIntervalRule(acc, tr, history)
+
+			// then
+			assert.Empty(t, repeated)
+			assert.Equal(t, []error{errors.New(ManyMerchantsSmallInterval)}, distinct)
+		},
+		"Should detect high amount instead of overflowing": func(t *testing.T) {
+			// given
+			acc := Account{VelocityLimits: &VelocityLimits{MaxAmount: Money(math.MaxInt64), AmountIntervalMinutes: 5}}
+
+			// then
+			assert.Equal(t, []error{errors.New(HighAmountSmallInterval)}, highAmountSmallIntervalRule(acc, Transaction{Amount: Money(math.MaxInt64), Time: tr.Time}, history))
+		},
+		"Should require an interval up to a day along with each limit": func(t *testing.T) {
+			// then
+			assert.NoError(t, VelocityLimits{}.validate())
+			assert.NoError(t, VelocityLimits{MaxMerchants: 3, MerchantIntervalMinutes: MaxVelocityIntervalMinutes}.validate())
+			assert.EqualError(t, VelocityLimits{MaxAmount: -1, AmountIntervalMinutes: 5}.validate(), InvalidAmount)
+			assert.EqualError(t, VelocityLimits{MaxAmount: Units(500)}.validate(), InvalidVelocityLimits)
+			assert.EqualError(t, VelocityLimits{AmountIntervalMinutes: 5}.validate(), InvalidVelocityLimits)
+			assert.EqualError(t, VelocityLimits{MaxMerchants: -1, MerchantIntervalMinutes: 5}.validate(), InvalidVelocityLimits)
+			assert.EqualError(t, VelocityLimits{MaxMerchants: 3, MerchantIntervalMinutes: MaxVelocityIntervalMinutes + 1}.validate(), InvalidVelocityLimits)
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			run(t)
+		})
+	}
+}